@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	backupService "github.com/ReidMason/plant-tracker/src/services/backupService"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/pressly/goose/v3"
+)
+
+// runBackup dumps the whole instance to a checksummed archive, e.g.
+//
+//	server backup -o plant-tracker.zip
+func runBackup(args []string) {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	output := flags.String("o", fmt.Sprintf("plant-tracker-backup-%s.zip", time.Now().Format("20060102-150405")), "file to write the backup archive to")
+	flags.Parse(args)
+
+	cfg := loadConfig()
+	ctx := context.Background()
+	sqldb := openMigrationDatabase(ctx, cfg.Database)
+	defer sqldb.Close()
+	pool := openPool(ctx, cfg.Database)
+	defer pool.Close()
+
+	schemaVersion, err := goose.GetDBVersion(sqldb)
+	if err != nil {
+		exitWithError("Failed to read schema version", err)
+	}
+
+	// Write to a temporary file first so a failed backup never leaves a
+	// truncated archive behind under the requested name
+	file, err := os.CreateTemp(filepath.Dir(*output), ".backup-*.zip")
+	if err != nil {
+		exitWithError("Failed to create backup file", err)
+	}
+	defer os.Remove(file.Name())
+
+	service := backupService.New(pool, database.New(pool))
+	manifest, err := service.Backup(ctx, file, schemaVersion)
+	if err != nil {
+		file.Close()
+		exitWithError("Failed to back up database", err)
+	}
+
+	if err := file.Close(); err != nil {
+		exitWithError("Failed to write backup file", err)
+	}
+
+	if err := os.Rename(file.Name(), *output); err != nil {
+		exitWithError("Failed to write backup file", err)
+	}
+
+	fmt.Printf("Backed up schema version %d to %s\n", manifest.SchemaVersion, *output)
+	printManifest(manifest)
+}
+
+// runRestore migrates an empty database and loads a backup archive into it, e.g.
+//
+//	server restore plant-tracker.zip
+func runRestore(args []string) {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: server restore <archive>")
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		exitWithError("Failed to open backup file", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		exitWithError("Failed to open backup file", err)
+	}
+
+	cfg := loadConfig()
+	ctx := context.Background()
+	sqldb := openMigrationDatabase(ctx, cfg.Database)
+	defer sqldb.Close()
+	pool := openPool(ctx, cfg.Database)
+	defer pool.Close()
+
+	if err := goose.Up(sqldb, "migrations"); err != nil {
+		exitWithError("Failed to migrate database", err)
+	}
+
+	schemaVersion, err := goose.GetDBVersion(sqldb)
+	if err != nil {
+		exitWithError("Failed to read schema version", err)
+	}
+
+	service := backupService.New(pool, database.New(pool))
+	manifest, err := service.Restore(ctx, file, info.Size(), schemaVersion)
+	if err != nil {
+		exitWithError("Failed to restore database", err)
+	}
+
+	fmt.Printf("Restored backup from %s\n", manifest.CreatedAt.Format(time.RFC3339))
+	printManifest(manifest)
+}
+
+func printManifest(manifest backupService.Manifest) {
+	for _, file := range manifest.Files {
+		fmt.Printf("  %-20s %8d rows  sha256:%s\n", file.Name, file.Rows, file.SHA256)
+	}
+}
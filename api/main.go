@@ -4,110 +4,143 @@ import (
 	"context"
 	"embed"
 	"fmt"
-	"net/http"
+	"log/slog"
 	"os"
 
 	"database/sql"
 
 	_ "github.com/lib/pq"
 
-	"github.com/ReidMason/plant-tracker/src/httpHandlers/eventsHandler"
-	plantsHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/plantsHandler"
-	usersHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/usersHandler"
-	eventsService "github.com/ReidMason/plant-tracker/src/services/eventsService"
-	plantsService "github.com/ReidMason/plant-tracker/src/services/plantsService"
-	usersService "github.com/ReidMason/plant-tracker/src/services/usersService"
-	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/ReidMason/plant-tracker/src/config"
+	"github.com/ReidMason/plant-tracker/src/logging"
+	"github.com/ReidMason/plant-tracker/src/tracing"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/pressly/goose/v3"
 )
 
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*") // Allow any origin
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+//go:embed migrations/*.sql
+var embedMigrations embed.FS
 
-		next.ServeHTTP(w, r)
-	})
+type command struct {
+	run         func(args []string)
+	name        string
+	description string
 }
 
-//go:embed migrations/*.sql
-var embedMigrations embed.FS
+var commands = []command{
+	{name: "serve", description: "Run the HTTP API (default)", run: runServe},
+	{name: "migrate", description: "Apply, roll back or inspect database migrations", run: runMigrate},
+	{name: "backup", description: "Dump the whole instance to a backup archive", run: runBackup},
+	{name: "restore", description: "Load a backup archive into an empty database", run: runRestore},
+	{name: "seed", description: "Generate demo data for development and load testing", run: runSeed},
+	{name: "version", description: "Print build and schema versions", run: runVersion},
+}
 
 func main() {
 	if _, err := os.Stat(".env"); err == nil {
 		if err := godotenv.Load(); err != nil {
-			panic("Error loading .env file")
+			exitWithError("Error loading .env file", err)
 		}
 	}
 
-	mux := http.NewServeMux()
+	// Running the binary without a command serves the API, as it always has
+	if len(os.Args) < 2 {
+		runServe(nil)
+		return
+	}
 
-	// Database connection
-	ctx := context.Background()
+	name := os.Args[1]
+	for _, command := range commands {
+		if command.name == name {
+			command.run(os.Args[2:])
+			return
+		}
+	}
 
-	dbConnectionString := "DB_CONNECTION_STRING"
-	connectionString := os.Getenv(dbConnectionString)
-	if connectionString == "" {
-		panic(fmt.Sprintf("%s environment variable not set", dbConnectionString))
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return
 	}
 
-	// Open *sql.DB for goose migrations
-	sqldb, err := sql.Open("postgres", connectionString)
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(2)
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: server <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", command.name, command.description)
+	}
+}
+
+// loadConfig loads and validates the configuration, exiting with every
+// problem listed if it is invalid, and installs the configured logger as the
+// default
+func loadConfig() config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		exitWithError("Invalid configuration", err)
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.Logging.Format, cfg.Logging.Level)
 	if err != nil {
-		panic(err)
+		exitWithError("Invalid logging configuration", err)
 	}
-	defer sqldb.Close()
+	slog.SetDefault(logger)
 
-	if err := sqldb.PingContext(ctx); err != nil {
-		fmt.Println(err)
-		panic("Failed to connect to database")
+	return cfg
+}
+
+// openMigrationDatabase opens the *sql.DB used by goose for migrations
+func openMigrationDatabase(ctx context.Context, cfg config.DatabaseConfig) *sql.DB {
+	sqldb, err := sql.Open("postgres", cfg.ConnectionString)
+	if err != nil {
+		exitWithError("Failed to open database", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.ConnectTimeout)
+	defer cancel()
+	if err := sqldb.PingContext(pingCtx); err != nil {
+		exitWithError("Failed to connect to database", err)
 	}
 
-	// Database migrations
 	goose.SetBaseFS(embedMigrations)
 
 	if err := goose.SetDialect("postgres"); err != nil {
-		fmt.Println(err)
-		panic(err)
+		exitWithError("Failed to configure migrations", err)
 	}
 
-	if err := goose.Up(sqldb, "migrations"); err != nil {
-		fmt.Println(err)
-		panic(err)
-	}
+	return sqldb
+}
 
-	// Open pgxpool.Pool for sqlc/database
-	pool, err := pgxpool.New(ctx, connectionString)
+// openPool opens the pgxpool.Pool used by sqlc/database, sized from config
+func openPool(ctx context.Context, cfg config.DatabaseConfig) *pgxpool.Pool {
+	poolConfig, err := pgxpool.ParseConfig(cfg.ConnectionString)
 	if err != nil {
-		fmt.Println(err)
-		panic("Failed to connect to database (pgxpool)")
+		exitWithError("Invalid database connection string", err)
 	}
-	defer pool.Close()
-
-	queries := database.New(pool)
 
-	// Set up services
-	userService := usersService.New(queries)
-	eventService := eventsService.New(queries, queries)
-	plantService := plantsService.New(queries, eventService)
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	poolConfig.ConnConfig.Tracer = tracing.QueryTracer()
 
-	mux.Handle("/users", usersHandler.New(userService))
-	mux.Handle("/users/{id}", usersHandler.New(userService))
-	mux.Handle("/users/{id}/plants", plantsHandler.New(plantService))
-	mux.Handle("/users/{userId}/plants/{plantId}", plantsHandler.New(plantService))
-	mux.Handle("/users/{userId}/plants/{plantId}/events", eventsHandler.New(eventService))
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		exitWithError("Failed to connect to database (pgxpool)", err)
+	}
 
-	// Wrap the mux with CORS middleware
-	corsHandler := corsMiddleware(mux)
+	return pool
+}
 
-	// Start the server with CORS support
-	http.ListenAndServe(":8080", corsHandler)
+func exitWithError(message string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", message, err)
+	os.Exit(1)
 }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pressly/goose/v3"
+)
+
+// runMigrate manages the schema independently of serving, e.g.
+//
+//	server migrate status
+func runMigrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: server migrate up|down|status|redo")
+		fmt.Fprintln(flags.Output())
+		fmt.Fprintln(flags.Output(), "  up      Apply all pending migrations")
+		fmt.Fprintln(flags.Output(), "  down    Roll back the most recent migration")
+		fmt.Fprintln(flags.Output(), "  status  List migrations and whether they have been applied")
+		fmt.Fprintln(flags.Output(), "  redo    Roll back and re-apply the most recent migration")
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	migrate, ok := map[string]func(*sql.DB, string, ...goose.OptionsFunc) error{
+		"up":     goose.Up,
+		"down":   goose.Down,
+		"status": goose.Status,
+		"redo":   goose.Redo,
+	}[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	sqldb := openMigrationDatabase(context.Background(), loadConfig().Database)
+	defer sqldb.Close()
+
+	if err := migrate(sqldb, "migrations"); err != nil {
+		exitWithError(fmt.Sprintf("Failed to migrate %s", flags.Arg(0)), err)
+	}
+}
+
+// latestMigrationVersion is the newest migration embedded in the binary. It
+// never changes while running so is read once at startup.
+func latestMigrationVersion() (int64, error) {
+	goose.SetBaseFS(embedMigrations)
+	migrations, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, err
+	}
+
+	return last.Version, nil
+}
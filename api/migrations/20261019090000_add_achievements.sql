@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE achievements (
+  userId   BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code     TEXT NOT NULL,
+  earnedAt TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (userId, code)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS achievements;
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE plants ADD COLUMN updatedAt TIMESTAMPTZ NOT NULL DEFAULT now();
+-- +goose StatementEnd
+
+-- updatedAt changes whenever a plant or any of its events do, so it can be
+-- used to tell whether anything a client has read about a plant is stale
+-- +goose StatementBegin
+CREATE FUNCTION touch_plant() RETURNS trigger AS $$
+BEGIN
+  NEW.updatedAt := now();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER plants_touch BEFORE UPDATE ON plants
+FOR EACH ROW EXECUTE FUNCTION touch_plant();
+-- +goose StatementEnd
+
+-- Statement level so that bulk inserts such as imports touch each plant once
+-- +goose StatementBegin
+CREATE FUNCTION touch_plants_for_events() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP IN ('INSERT', 'UPDATE') THEN
+    UPDATE plants SET updatedAt = now() WHERE id IN (SELECT plantId FROM new_events);
+  END IF;
+  IF TG_OP IN ('UPDATE', 'DELETE') THEN
+    UPDATE plants SET updatedAt = now() WHERE id IN (SELECT plantId FROM old_events);
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER events_insert_touch_plants AFTER INSERT ON events
+REFERENCING NEW TABLE AS new_events
+FOR EACH STATEMENT EXECUTE FUNCTION touch_plants_for_events();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER events_update_touch_plants AFTER UPDATE ON events
+REFERENCING OLD TABLE AS old_events NEW TABLE AS new_events
+FOR EACH STATEMENT EXECUTE FUNCTION touch_plants_for_events();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER events_delete_touch_plants AFTER DELETE ON events
+REFERENCING OLD TABLE AS old_events
+FOR EACH STATEMENT EXECUTE FUNCTION touch_plants_for_events();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS events_delete_touch_plants ON events;
+DROP TRIGGER IF EXISTS events_update_touch_plants ON events;
+DROP TRIGGER IF EXISTS events_insert_touch_plants ON events;
+DROP FUNCTION IF EXISTS touch_plants_for_events();
+DROP TRIGGER IF EXISTS plants_touch ON plants;
+DROP FUNCTION IF EXISTS touch_plant();
+ALTER TABLE plants DROP COLUMN IF EXISTS updatedAt;
+-- +goose StatementEnd
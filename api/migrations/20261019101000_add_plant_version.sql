@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE plants ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- The version goes up with every change to a plant, including the touches
+-- from its events changing, so it identifies what a client last read
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION touch_plant() RETURNS trigger AS $$
+BEGIN
+  NEW.updatedAt := now();
+  NEW.version := OLD.version + 1;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION touch_plant() RETURNS trigger AS $$
+BEGIN
+  NEW.updatedAt := now();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE plants DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- A row is claimed with a null status while its request is handled and
+-- completed with the response to replay for repeats of the request
+-- +goose StatementBegin
+CREATE TABLE idempotency_keys (
+  client      TEXT NOT NULL,
+  key         TEXT NOT NULL,
+  requestHash BYTEA NOT NULL,
+  status      INTEGER,
+  headers     JSONB,
+  body        BYTEA,
+  createdAt   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (client, key)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idempotency_keys_createdat_idx ON idempotency_keys (createdAt);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- actorIds are the users who did the care, usually just one but more when a
+-- housemate's duplicate of the event was merged into it
+-- +goose StatementBegin
+ALTER TABLE events ADD COLUMN actorIds BIGINT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- Events recorded before actors were tracked were made by the plant's owner
+-- +goose StatementBegin
+UPDATE events e SET actorIds = ARRAY[p.userId] FROM plants p WHERE p.id = e.plantId;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN IF EXISTS actorIds;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Achievements count the care each user did, found by their ID in actorIds
+-- +goose StatementBegin
+CREATE INDEX events_actorids_idx ON events USING GIN (actorIds);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS events_actorids_idx;
+-- +goose StatementEnd
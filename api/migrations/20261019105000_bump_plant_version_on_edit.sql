@@ -0,0 +1,26 @@
+-- +goose Up
+-- Only changes to a plant's own columns bump its version, so care recorded
+-- for it, e.g. by a housemate, doesn't fail the owner's next rename with 412.
+-- updatedAt still changes with its events for the list and plant ETags.
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION touch_plant() RETURNS trigger AS $$
+BEGIN
+  NEW.updatedAt := now();
+  IF NEW.name IS DISTINCT FROM OLD.name OR NEW.userId IS DISTINCT FROM OLD.userId THEN
+    NEW.version := OLD.version + 1;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION touch_plant() RETURNS trigger AS $$
+BEGIN
+  NEW.updatedAt := now();
+  NEW.version := OLD.version + 1;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/config"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/achievementsHandler/achievementDtos"
+	docsHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/docsHandler"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/eventsHandler/eventDtos"
+	healthHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/healthHandler"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/importHandler/importDtos"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/plantsHandler/plantDtos"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/statsHandler/statDtos"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/usersHandler/userDtos"
+	"github.com/ReidMason/plant-tracker/src/services/exportService"
+	"github.com/ReidMason/plant-tracker/src/services/importService"
+)
+
+type openAPISchema struct {
+	Type       any                      `json:"type"`
+	Ref        string                   `json:"$ref"`
+	Properties map[string]openAPISchema `json:"properties"`
+	OneOf      []openAPISchema          `json:"oneOf"`
+}
+
+type openAPIDocument struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+func loadSpec(t *testing.T) openAPIDocument {
+	t.Helper()
+
+	var document openAPIDocument
+	if err := json.Unmarshal(docsHandler.Spec, &document); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %s", err)
+	}
+	return document
+}
+
+// specMux routes requests the way the server does, with every feature enabled
+func specMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	features := config.FeaturesConfig{Achievements: true, Export: true, Import: true}
+	for _, route := range apiRoutes(features, services{}) {
+		mux.Handle(route.pattern, route.handler)
+	}
+	healthHandler.New(nil, nil, healthHandler.BuildInfo{}).Register(mux)
+	return mux
+}
+
+func TestSpecDescribesEveryRoute(t *testing.T) {
+	document := loadSpec(t)
+
+	features := config.FeaturesConfig{Achievements: true, Export: true, Import: true}
+	for _, route := range apiRoutes(features, services{}) {
+		if route.deprecated {
+			continue
+		}
+
+		method, path, _ := strings.Cut(route.pattern, " ")
+		if _, ok := document.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("route %s is served but missing from openapi.json", route.pattern)
+		}
+	}
+}
+
+func TestSpecPathsAreServed(t *testing.T) {
+	document := loadSpec(t)
+	mux := specMux()
+
+	for path, item := range document.Paths {
+		for method := range item {
+			if !slices.Contains(httpMethods, method) {
+				continue
+			}
+
+			// Any value will do for path parameters, only the route is checked
+			target := path
+			for strings.Contains(target, "{") {
+				start := strings.Index(target, "{")
+				end := strings.Index(target, "}")
+				target = target[:start] + "1" + target[end+1:]
+			}
+
+			request := httptest.NewRequest(strings.ToUpper(method), target, nil)
+			_, pattern := mux.Handler(request)
+			if pattern == "" {
+				t.Errorf("openapi.json documents %s %s but no route serves it", strings.ToUpper(method), path)
+				continue
+			}
+
+			// Catch a documented method being routed to a different path
+			if _, routedPath, _ := strings.Cut(pattern, " "); routedPath != path {
+				t.Errorf("openapi.json documents %s %s but it is served by %s", strings.ToUpper(method), path, pattern)
+			}
+		}
+	}
+}
+
+func TestSpecSchemasMatchDtos(t *testing.T) {
+	document := loadSpec(t)
+
+	dtos := map[string]any{
+		"UserResponseDto":         userDtos.UserResponseDto{},
+		"CreateUserDto":           userDtos.CreateUserDto{},
+		"PlantResponseDto":        plantDtos.PlantResponseDto{},
+		"CreatePlantDto":          plantDtos.CreatePlantDto{},
+		"UpdatePlantDto":          plantDtos.UpdatePlantDto{},
+		"PatchPlantDto":           plantDtos.PatchPlantDto{},
+		"EventResponseDto":        eventDtos.EventResponseDto{},
+		"CreateEventDto":          eventDtos.CreateEventDto{},
+		"StatsResponseDto":        statDtos.StatsResponseDto{},
+		"IntervalStatsDto":        statDtos.IntervalStatsDto{},
+		"BucketDto":               statDtos.BucketDto{},
+		"AchievementsResponseDto": achievementDtos.AchievementsResponseDto{},
+		"StreaksDto":              achievementDtos.StreaksDto{},
+		"AchievementDto":          achievementDtos.AchievementDto{},
+		"ImportReportDto":         importDtos.ImportReportDto{},
+		"ImportedPlantDto":        importDtos.ImportedPlantDto{},
+		"ImportMapping":           importService.Mapping{},
+		"ExportDocument":          exportService.Document{},
+		"UserRecord":              exportService.UserRecord{},
+		"PlantRecord":             exportService.PlantRecord{},
+		"EventRecord":             exportService.EventRecord{},
+	}
+
+	for name, dto := range dtos {
+		schema, ok := document.Components.Schemas[name]
+		if !ok {
+			t.Errorf("%s is missing from openapi.json", name)
+			continue
+		}
+
+		dtoType := reflect.TypeOf(dto)
+		fields := make(map[string]reflect.Type)
+		for i := range dtoType.NumField() {
+			field := dtoType.Field(i)
+			jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if jsonName == "" || jsonName == "-" {
+				continue
+			}
+			fields[jsonName] = field.Type
+		}
+
+		for jsonName, fieldType := range fields {
+			property, ok := schema.Properties[jsonName]
+			if !ok {
+				t.Errorf("%s.%s is missing from openapi.json", name, jsonName)
+				continue
+			}
+			if expected, actual := jsonType(fieldType), schemaType(document, property); expected != actual {
+				t.Errorf("%s.%s is a %s in openapi.json but the DTO encodes a %s", name, jsonName, actual, expected)
+			}
+		}
+
+		for jsonName := range schema.Properties {
+			if _, ok := fields[jsonName]; !ok {
+				t.Errorf("%s.%s is in openapi.json but not on the DTO", name, jsonName)
+			}
+		}
+	}
+}
+
+// jsonType is the JSON type encoding/json produces for a Go type, ignoring null
+func jsonType(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// schemaType is the JSON type a schema describes, ignoring null
+func schemaType(document openAPIDocument, schema openAPISchema) string {
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		return schemaType(document, document.Components.Schemas[name])
+	}
+
+	for _, option := range schema.OneOf {
+		if option.Type != "null" {
+			return schemaType(document, option)
+		}
+	}
+
+	switch schemaType := schema.Type.(type) {
+	case string:
+		return schemaType
+	case []any:
+		for _, option := range schemaType {
+			if option != "null" {
+				return option.(string)
+			}
+		}
+	}
+	return ""
+}
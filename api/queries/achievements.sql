@@ -0,0 +1,40 @@
+-- name: GetAchievementsByUserId :many
+SELECT * FROM achievements WHERE userId = $1 ORDER BY earnedAt;
+
+-- name: CreateAchievement :execrows
+INSERT INTO achievements (userId, code, earnedAt)
+VALUES ($1, $2, $3)
+ON CONFLICT (userId, code) DO NOTHING;
+
+-- name: GetCareProgressByUserId :one
+-- Care is credited to whoever did it, including for a housemate's plants
+SELECT COUNT(*) FILTER (WHERE e.eventtype = 1)::bigint AS water_count,
+       COUNT(*) FILTER (WHERE e.eventtype = 2)::bigint AS fertilize_count,
+       COUNT(DISTINCT e.plantid)::bigint AS plants_cared_for,
+       COUNT(DISTINCT e.plantid) FILTER (WHERE e.eventtype = 1)::bigint AS plants_watered
+FROM events e
+WHERE e.actorIds @> ARRAY[sqlc.arg(user_id)::bigint];
+
+-- name: GetCareStreakByUserId :one
+WITH gaps AS (
+  SELECT e.plantid,
+         e.timestamp,
+         e.timestamp - LAG(e.timestamp) OVER (PARTITION BY e.plantid ORDER BY e.timestamp) AS gap
+  FROM events e
+  JOIN plants p ON p.id = e.plantid
+  WHERE p.userid = sqlc.arg(user_id) AND e.eventtype = sqlc.arg(event_type)
+),
+latest AS (
+  SELECT plantid, MAX(timestamp) AS timestamp
+  FROM gaps
+  GROUP BY plantid
+)
+SELECT COALESCE(
+         (SELECT MAX(timestamp) FROM gaps WHERE gap > make_interval(days => sqlc.arg(interval_days)::int)),
+         (SELECT MIN(timestamp) FROM gaps),
+         sqlc.arg(now)::timestamptz
+       )::timestamptz AS streak_start,
+       EXISTS (
+         SELECT 1 FROM latest
+         WHERE latest.timestamp + make_interval(days => sqlc.arg(interval_days)::int) < sqlc.arg(now)::timestamptz
+       ) AS overdue;
@@ -0,0 +1,36 @@
+-- name: CountUserData :one
+SELECT (SELECT COUNT(*) FROM users)::bigint AS users,
+       (SELECT COUNT(*) FROM plants)::bigint AS plants,
+       (SELECT COUNT(*) FROM events)::bigint AS events;
+
+-- name: RestoreUsers :copyfrom
+INSERT INTO users (id, name, colour) VALUES ($1, $2, $3);
+
+-- name: RestorePlants :copyfrom
+INSERT INTO plants (id, name, userId) VALUES ($1, $2, $3);
+
+-- name: RestoreEvents :copyfrom
+INSERT INTO events (id, plantId, eventType, note, timestamp, actorIds) VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: BackfillEventActors :exec
+-- Archives from before events had actors restore them with none, so credit
+-- the plant's owner as the migration did
+UPDATE events e SET actorIds = ARRAY[p.userId]
+FROM plants p
+WHERE p.id = e.plantId AND e.actorIds = '{}';
+
+-- name: RestoreAchievements :copyfrom
+INSERT INTO achievements (userId, code, earnedAt) VALUES ($1, $2, $3);
+
+-- name: RestoreEventType :exec
+INSERT INTO eventTypes (id, name) VALUES ($1, $2)
+ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name;
+
+-- name: ResetUsersSequence :exec
+SELECT setval(pg_get_serial_sequence('users', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM users;
+
+-- name: ResetPlantsSequence :exec
+SELECT setval(pg_get_serial_sequence('plants', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM plants;
+
+-- name: ResetEventsSequence :exec
+SELECT setval(pg_get_serial_sequence('events', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM events;
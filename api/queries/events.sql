@@ -5,12 +5,38 @@ SELECT * FROM events WHERE plantId = $1;
 SELECT * FROM events WHERE id = $1;
 
 -- name: CreateEvent :one
-INSERT INTO events (plantId, eventType, note, timestamp)
-VALUES ($1, $2, $3, $4)
+INSERT INTO events (plantId, eventType, note, timestamp, actorIds)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetLatestEventsByTypeForPlant :many
-SELECT DISTINCT ON (eventtype) id, plantid, eventtype, note, timestamp
+SELECT DISTINCT ON (eventtype) id, plantid, eventtype, note, timestamp, actorids
 FROM events 
 WHERE plantid = $1 AND eventtype IN (1, 2)
 ORDER BY eventtype, timestamp DESC; 
+
+-- name: CreateEvents :copyfrom
+INSERT INTO events (plantId, eventType, note, timestamp, actorIds)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetRecentEvent :one
+SELECT * FROM events
+WHERE plantId = $1 AND eventType = $2 AND timestamp >= sqlc.arg(since)
+ORDER BY timestamp DESC
+LIMIT 1;
+
+-- name: MergeIntoEvent :one
+-- Adds a duplicate's note and actor to an event, leaving out an empty note
+-- and an actor who is already listed
+UPDATE events
+SET note = CASE
+      WHEN sqlc.arg(note)::text = '' THEN note
+      WHEN note = '' THEN sqlc.arg(note)::text
+      ELSE note || E'\n' || sqlc.arg(note)::text
+    END,
+    actorIds = CASE
+      WHEN sqlc.arg(actor_id)::bigint = ANY(actorIds) THEN actorIds
+      ELSE array_append(actorIds, sqlc.arg(actor_id)::bigint)
+    END
+WHERE id = sqlc.arg(id)
+RETURNING *;
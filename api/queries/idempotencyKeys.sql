@@ -0,0 +1,29 @@
+-- name: ClaimIdempotencyKey :one
+-- Claims a key for a new request. A key that has expired, or whose request
+-- was abandoned without completing, is taken over. Returns no row if the key
+-- is held by another request.
+INSERT INTO idempotency_keys (client, key, requestHash)
+VALUES (sqlc.arg(client), sqlc.arg(key), sqlc.arg(request_hash))
+ON CONFLICT (client, key) DO UPDATE
+SET requestHash = EXCLUDED.requestHash,
+    status = NULL,
+    headers = NULL,
+    body = NULL,
+    createdAt = now()
+WHERE idempotency_keys.createdAt < sqlc.arg(expired_before)::timestamptz
+   OR (idempotency_keys.status IS NULL AND idempotency_keys.createdAt < sqlc.arg(abandoned_before)::timestamptz)
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys WHERE client = $1 AND key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status = $3, headers = $4, body = $5
+WHERE client = $1 AND key = $2;
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE client = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys WHERE createdAt < $1;
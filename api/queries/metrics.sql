@@ -0,0 +1,20 @@
+-- name: GetPlantCareSnapshot :many
+WITH latest AS (
+  SELECT p.id,
+         p.name,
+         p.userid,
+         MAX(e.timestamp) FILTER (WHERE e.eventtype = 1) AS last_watered,
+         MAX(e.timestamp) FILTER (WHERE e.eventtype = 2) AS last_fertilized
+  FROM plants p
+  LEFT JOIN events e ON e.plantid = p.id
+  GROUP BY p.id
+)
+SELECT id,
+       name,
+       userid,
+       (last_watered IS NOT NULL)::bool AS watered,
+       COALESCE(EXTRACT(EPOCH FROM now() - last_watered) / 3600, 0)::float8 AS hours_since_watered,
+       COALESCE(last_watered + make_interval(days => sqlc.arg(water_interval_days)::int) < now(), false)::bool AS water_overdue,
+       COALESCE(last_fertilized + make_interval(days => sqlc.arg(fertilizer_interval_days)::int) < now(), false)::bool AS fertilizer_overdue
+FROM latest
+ORDER BY id;
@@ -4,6 +4,11 @@ SELECT * FROM plants WHERE userId = $1;
 -- name: GetPlantById :one
 SELECT * FROM plants WHERE id = $1;
 
+-- name: LockPlant :exec
+-- Holds the plant's row until the transaction ends, so care recorded for it
+-- at the same time is checked for duplicates one at a time
+SELECT id FROM plants WHERE id = $1 FOR UPDATE;
+
 -- name: CreatePlant :one
 INSERT INTO plants (name, userId) VALUES ($1, $2)
 RETURNING *; 
@@ -11,5 +16,26 @@ RETURNING *;
 -- name: UpdatePlant :one
 UPDATE plants
 SET name = $2
-WHERE id = $1
+WHERE id = $1 AND version = $3
+RETURNING *;
+
+-- name: PatchPlant :one
+UPDATE plants
+SET name = COALESCE(sqlc.narg(name), name)
+WHERE id = sqlc.arg(id) AND version = sqlc.arg(version)
 RETURNING *;
+
+-- name: DeletePlant :execrows
+DELETE FROM plants
+WHERE id = $1 AND version = $2;
+
+-- name: CreatePlants :many
+INSERT INTO plants (name, userId)
+SELECT unnest(sqlc.arg(names)::text[]), sqlc.arg(user_id)
+RETURNING *;
+
+-- name: GetPlantsVersionByUserId :one
+SELECT COUNT(*)::bigint AS plant_count,
+       COALESCE(MAX(updatedAt), 'epoch')::timestamptz AS updated_at
+FROM plants
+WHERE userId = $1;
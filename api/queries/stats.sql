@@ -0,0 +1,61 @@
+-- name: GetIntervalStatsByUserId :many
+WITH intervals AS (
+  SELECT e.eventtype,
+         (EXTRACT(EPOCH FROM e.timestamp - LAG(e.timestamp) OVER (PARTITION BY e.plantid, e.eventtype ORDER BY e.timestamp)) / 86400)::float8 AS days
+  FROM events e
+  JOIN plants p ON p.id = e.plantid
+  WHERE p.userid = sqlc.arg(user_id)
+)
+SELECT eventtype,
+       COUNT(*)::bigint AS event_count,
+       COUNT(days)::bigint AS interval_count,
+       COALESCE(AVG(days), 0)::float8 AS mean_days,
+       COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY days), 0)::float8 AS median_days,
+       COALESCE(MAX(days), 0)::float8 AS longest_gap_days,
+       (COUNT(days) FILTER (WHERE days <= CASE eventtype
+         WHEN 1 THEN sqlc.arg(water_interval_days)::float8
+         ELSE sqlc.arg(fertilizer_interval_days)::float8
+       END))::bigint AS on_time_count
+FROM intervals
+GROUP BY eventtype
+ORDER BY eventtype;
+
+-- name: GetIntervalStatsByPlantId :many
+WITH intervals AS (
+  SELECT e.eventtype,
+         (EXTRACT(EPOCH FROM e.timestamp - LAG(e.timestamp) OVER (PARTITION BY e.eventtype ORDER BY e.timestamp)) / 86400)::float8 AS days
+  FROM events e
+  WHERE e.plantid = sqlc.arg(plant_id)
+)
+SELECT eventtype,
+       COUNT(*)::bigint AS event_count,
+       COUNT(days)::bigint AS interval_count,
+       COALESCE(AVG(days), 0)::float8 AS mean_days,
+       COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY days), 0)::float8 AS median_days,
+       COALESCE(MAX(days), 0)::float8 AS longest_gap_days,
+       (COUNT(days) FILTER (WHERE days <= CASE eventtype
+         WHEN 1 THEN sqlc.arg(water_interval_days)::float8
+         ELSE sqlc.arg(fertilizer_interval_days)::float8
+       END))::bigint AS on_time_count
+FROM intervals
+GROUP BY eventtype
+ORDER BY eventtype;
+
+-- name: GetEventCountsByUserId :many
+SELECT date_trunc(sqlc.arg(bucket)::text, e.timestamp)::timestamptz AS bucket,
+       e.eventtype,
+       COUNT(*)::bigint AS event_count
+FROM events e
+JOIN plants p ON p.id = e.plantid
+WHERE p.userid = sqlc.arg(user_id)
+GROUP BY 1, 2
+ORDER BY 1, 2;
+
+-- name: GetEventCountsByPlantId :many
+SELECT date_trunc(sqlc.arg(bucket)::text, e.timestamp)::timestamptz AS bucket,
+       e.eventtype,
+       COUNT(*)::bigint AS event_count
+FROM events e
+WHERE e.plantid = sqlc.arg(plant_id)
+GROUP BY 1, 2
+ORDER BY 1, 2;
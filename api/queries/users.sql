@@ -13,3 +13,8 @@ INSERT INTO users (
   $1, $2
 )
 RETURNING *;
+
+-- name: CreateUsers :many
+INSERT INTO users (name, colour)
+SELECT unnest(sqlc.arg(names)::text[]), unnest(sqlc.arg(colours)::text[])
+RETURNING *;
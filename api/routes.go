@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/config"
+	achievementsHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/achievementsHandler"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/eventsHandler"
+	exportHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/exportHandler"
+	importHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/importHandler"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/middleware"
+	plantsHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/plantsHandler"
+	statsHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/statsHandler"
+	usersHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/usersHandler"
+	achievementsService "github.com/ReidMason/plant-tracker/src/services/achievementsService"
+	eventsService "github.com/ReidMason/plant-tracker/src/services/eventsService"
+	exportService "github.com/ReidMason/plant-tracker/src/services/exportService"
+	importService "github.com/ReidMason/plant-tracker/src/services/importService"
+	plantsService "github.com/ReidMason/plant-tracker/src/services/plantsService"
+	statsService "github.com/ReidMason/plant-tracker/src/services/statsService"
+	usersService "github.com/ReidMason/plant-tracker/src/services/usersService"
+)
+
+// services are what the API's handlers are built from
+type services struct {
+	users        usersService.GetUsersService
+	plants       plantsService.GetPlantsService
+	events       eventsService.EventsService
+	stats        statsService.StatsService
+	achievements achievementsService.AchievementsService
+	export       exportService.ExportService
+	imports      importService.ImportService
+}
+
+type route struct {
+	handler http.HandlerFunc
+	pattern string
+	// name is the pattern without the version prefix, the same for a route
+	// in every version and its unversioned alias
+	name       string
+	deprecated bool
+}
+
+// apiVersion is a version of the API served under its own path prefix. Every
+// version is built from the same services, so a new one only needs its own
+// handlers and DTOs where its responses differ.
+type apiVersion struct {
+	prefix string
+	routes func(features config.FeaturesConfig, s services) []route
+}
+
+var apiVersions = []apiVersion{
+	{prefix: "/api/v1", routes: v1Routes},
+}
+
+// The unversioned paths are what v1 was served at before the API was
+// versioned. They stay as aliases of v1 until the sunset date so deployed
+// clients have time to move.
+var (
+	unversionedDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	unversionedSunset       = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// apiRoutes lists the routes served behind CORS, leaving out features that
+// are turned off. Every route that isn't deprecated must be described in the
+// OpenAPI document.
+func apiRoutes(features config.FeaturesConfig, s services) []route {
+	routes := make([]route, 0)
+	for _, version := range apiVersions {
+		for _, r := range version.routes(features, s) {
+			method, path, _ := strings.Cut(r.pattern, " ")
+			routes = append(routes, route{pattern: method + " " + version.prefix + path, name: r.pattern, handler: r.handler})
+		}
+	}
+
+	// The unversioned paths only add deprecation headers, and otherwise
+	// behave exactly like version 1, including requiring If-Match on writes
+	deprecated := middleware.Deprecated(unversionedDeprecatedAt, unversionedSunset, "/api/v1")
+	for _, r := range v1Routes(features, s) {
+		handler := deprecated(r.handler).ServeHTTP
+		routes = append(routes, route{pattern: r.pattern, name: r.pattern, handler: handler, deprecated: true})
+	}
+
+	return routes
+}
+
+// v1Routes are the version 1 routes, relative to the version's prefix
+func v1Routes(features config.FeaturesConfig, s services) []route {
+	users := usersHandler.New(s.users)
+	plants := plantsHandler.New(s.plants)
+	events := eventsHandler.New(s.events)
+	stats := statsHandler.New(s.stats)
+
+	routes := []route{
+		{pattern: "GET /users", handler: users.GetUsers},
+		{pattern: "POST /users", handler: users.CreateUser},
+		{pattern: "GET /users/{id}", handler: users.GetUser},
+		{pattern: "GET /users/{id}/plants", handler: plants.GetPlants},
+		{pattern: "POST /users/{id}/plants", handler: plants.CreatePlant},
+		{pattern: "GET /users/{id}/stats", handler: stats.GetUserStats},
+		{pattern: "GET /users/{userId}/plants/{plantId}", handler: plants.GetPlant},
+		{pattern: "PUT /users/{userId}/plants/{plantId}", handler: plants.UpdatePlant},
+		{pattern: "PATCH /users/{userId}/plants/{plantId}", handler: plants.PatchPlant},
+		{pattern: "DELETE /users/{userId}/plants/{plantId}", handler: plants.DeletePlant},
+		{pattern: "GET /users/{userId}/plants/{plantId}/events", handler: events.GetEvents},
+		{pattern: "POST /users/{userId}/plants/{plantId}/events", handler: events.CreateEvent},
+		{pattern: "GET /users/{userId}/plants/{plantId}/stats", handler: stats.GetPlantStats},
+	}
+
+	if features.Achievements {
+		routes = append(routes, route{pattern: "GET /users/{id}/achievements", handler: achievementsHandler.New(s.achievements).GetAchievements})
+	}
+	if features.Export {
+		routes = append(routes, route{pattern: "GET /users/{id}/export", handler: exportHandler.New(s.export).Export})
+	}
+	if features.Import {
+		routes = append(routes, route{pattern: "POST /users/{id}/import", handler: importHandler.New(s.imports).Import})
+	}
+
+	return routes
+}
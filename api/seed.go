@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	seedService "github.com/ReidMason/plant-tracker/src/services/seedService"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/pressly/goose/v3"
+)
+
+// runSeed fills the database with generated users, plants and care history
+// for development and load testing, e.g.
+//
+//	server seed -users 200 -plants 25 -days 730
+func runSeed(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	users := flags.Int("users", 3, "number of users to create")
+	plants := flags.Int("plants", 8, "number of plants to create per user")
+	days := flags.Int("days", 180, "days of care history to generate")
+	seed := flags.Uint64("seed", 0, "random seed, 0 picks one at random")
+	batchSize := flags.Int("batch", 5000, "rows inserted per batch")
+	flags.Parse(args)
+
+	if *users < 1 || *plants < 0 || *days < 1 || *batchSize < 1 {
+		fmt.Fprintln(os.Stderr, "-users, -days and -batch must be at least 1 and -plants cannot be negative")
+		os.Exit(2)
+	}
+
+	if *seed == 0 {
+		*seed = uint64(time.Now().UnixNano())
+	}
+
+	cfg := loadConfig()
+	ctx := context.Background()
+	sqldb := openMigrationDatabase(ctx, cfg.Database)
+	defer sqldb.Close()
+
+	if err := goose.Up(sqldb, "migrations"); err != nil {
+		exitWithError("Failed to migrate database", err)
+	}
+
+	pool := openPool(ctx, cfg.Database)
+	defer pool.Close()
+
+	started := time.Now()
+	service := seedService.New(database.New(pool))
+	summary, err := service.Seed(ctx, seedService.Options{
+		Now:           started,
+		Users:         *users,
+		PlantsPerUser: *plants,
+		Days:          *days,
+		BatchSize:     *batchSize,
+		Seed:          *seed,
+	})
+	if err != nil {
+		exitWithError("Failed to seed database", err)
+	}
+
+	fmt.Printf("Seeded %d users, %d plants and %d events in %s (seed %d)\n",
+		summary.Users, summary.Plants, summary.Events, time.Since(started).Round(time.Millisecond), *seed)
+}
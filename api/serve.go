@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/config"
+	docsHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/docsHandler"
+	healthHandler "github.com/ReidMason/plant-tracker/src/httpHandlers/healthHandler"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/middleware"
+	"github.com/ReidMason/plant-tracker/src/metrics"
+	"github.com/ReidMason/plant-tracker/src/rateLimit"
+	achievementsService "github.com/ReidMason/plant-tracker/src/services/achievementsService"
+	eventsService "github.com/ReidMason/plant-tracker/src/services/eventsService"
+	exportService "github.com/ReidMason/plant-tracker/src/services/exportService"
+	idempotencyService "github.com/ReidMason/plant-tracker/src/services/idempotencyService"
+	importService "github.com/ReidMason/plant-tracker/src/services/importService"
+	plantsService "github.com/ReidMason/plant-tracker/src/services/plantsService"
+	statsService "github.com/ReidMason/plant-tracker/src/services/statsService"
+	usersService "github.com/ReidMason/plant-tracker/src/services/usersService"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/ReidMason/plant-tracker/src/stores/dbErrors"
+	exportStore "github.com/ReidMason/plant-tracker/src/stores/exportStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+	"github.com/pressly/goose/v3"
+)
+
+// How serve treats migrations that have not been applied yet
+const (
+	migrationsApply   = "apply"
+	migrationsRequire = "require"
+	migrationsIgnore  = "ignore"
+)
+
+// runServe starts the API. By default pending migrations are applied first;
+// deployments that migrate as a separate step can pass -migrations=require to
+// refuse to start against an out of date schema instead.
+func runServe(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	migrations := flags.String("migrations", migrationsApply, "how to handle pending migrations: apply, require or ignore")
+	flags.Parse(args)
+
+	cfg := loadConfig()
+	mux := http.NewServeMux()
+
+	// Database connection
+	ctx := context.Background()
+	sqldb := openMigrationDatabase(ctx, cfg.Database)
+	defer sqldb.Close()
+
+	// Database migrations
+	latestSchemaVersion, err := latestMigrationVersion()
+	if err != nil {
+		exitWithError("Failed to read migrations", err)
+	}
+	switch *migrations {
+	case migrationsApply:
+		if err := goose.Up(sqldb, "migrations"); err != nil {
+			exitWithError("Failed to migrate database", err)
+		}
+	case migrationsRequire:
+		current, err := goose.GetDBVersionContext(ctx, sqldb)
+		if err != nil {
+			exitWithError("Failed to check migrations", err)
+		}
+		if current < latestSchemaVersion {
+			fmt.Fprintf(os.Stderr, "Database schema is at version %d but %d is required, run \"server migrate up\" first\n", current, latestSchemaVersion)
+			os.Exit(1)
+		}
+	case migrationsIgnore:
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid -migrations value %q, expected apply, require or ignore\n", *migrations)
+		os.Exit(2)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:       cfg.Tracing.Exporter,
+		Endpoint:       cfg.Tracing.OTLPEndpoint,
+		SampleRatio:    cfg.Tracing.SampleRatio,
+		ServiceVersion: version,
+	})
+	if err != nil {
+		exitWithError("Failed to set up tracing", err)
+	}
+
+	pool := openPool(ctx, cfg.Database)
+
+	background := newWorkers()
+
+	// Queries report missing rows and constraint violations as domainErrors so
+	// handlers can answer with 404, 409 or 422 instead of 500
+	queries := database.New(dbErrors.Wrap(pool))
+
+	apiMetrics := metrics.New()
+	apiMetrics.RegisterPool(pool)
+	if cfg.Metrics.Enabled && cfg.Metrics.HouseholdRefreshInterval > 0 {
+		household := apiMetrics.RegisterHousehold(queries)
+		background.Go(func(ctx context.Context) {
+			household.Run(ctx, cfg.Metrics.HouseholdRefreshInterval)
+		})
+	}
+
+	// Set up services
+	userService := usersService.New(queries)
+	achievementService := achievementsService.New(queries, queries)
+	eventListeners := []eventsService.EventListener{apiMetrics}
+	var importAchievements importService.AchievementsEvaluator
+	if cfg.Features.Achievements {
+		eventListeners = append(eventListeners, achievementService)
+		importAchievements = achievementService
+	}
+	dedupe := map[int32]eventsService.Dedupe{
+		1: {Window: cfg.Dedupe.Water.Window, Action: eventsService.DedupeAction(cfg.Dedupe.Water.Action)},
+		2: {Window: cfg.Dedupe.Fertilize.Window, Action: eventsService.DedupeAction(cfg.Dedupe.Fertilize.Action)},
+	}
+	eventService := eventsService.New(pool, queries, queries, queries, dedupe, eventListeners...)
+	plantService := plantsService.New(queries, eventService)
+	statService := statsService.New(queries, queries, queries)
+
+	routes := apiRoutes(cfg.Features, services{
+		users:        userService,
+		plants:       plantService,
+		events:       eventService,
+		stats:        statService,
+		achievements: achievementService,
+		export:       exportService.New(exportStore.New(dbErrors.Wrap(pool))),
+		imports:      importService.New(pool, queries, importAchievements),
+	})
+	var limiter *rateLimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = newRateLimiter(cfg.RateLimit, routes)
+		background.Go(func(ctx context.Context) {
+			limiter.Run(ctx, time.Minute)
+		})
+	}
+
+	var idempotency func(http.Handler) http.Handler
+	if cfg.Idempotency.Enabled {
+		// A request still running after the write timeout can't send its
+		// response, so its key is treated as abandoned after that
+		keys := idempotencyService.New(queries, cfg.Idempotency.Retention, cfg.Server.WriteTimeout)
+		idempotency = middleware.Idempotency(keys)
+		background.Go(func(ctx context.Context) {
+			keys.Run(ctx, cfg.Idempotency.CleanupInterval)
+		})
+	}
+
+	for _, route := range routes {
+		var handler http.Handler = route.handler
+		if idempotency != nil && strings.HasPrefix(route.pattern, http.MethodPost+" ") {
+			handler = idempotency(handler)
+		}
+		if limiter != nil {
+			handler = middleware.RateLimit(limiter, route.name)(handler)
+		}
+		mux.Handle(route.pattern, handler)
+	}
+
+	// Wrap the mux with CORS middleware, and metrics around that so preflight
+	// requests are counted too
+	corsHandler := middleware.CORS(cfg.CORS.AllowedOrigins, cfg.CORS.AllowedHeaders)(tracing.RouteName(middleware.RouteErrors(mux)))
+	apiHandler := middleware.Metrics(apiMetrics)(corsHandler)
+
+	// Health and metrics endpoints are for the orchestrator and scraper rather
+	// than browsers so they sit outside CORS handling
+	root := http.NewServeMux()
+	healthHandler.New(pool, func(ctx context.Context) (int64, error) {
+		return goose.GetDBVersionContext(ctx, sqldb)
+	}, healthHandler.BuildInfo{
+		Version:             version,
+		Commit:              buildCommit(),
+		LatestSchemaVersion: latestSchemaVersion,
+	}).Register(root)
+	docsHandler.New().Register(root)
+	if cfg.Metrics.Enabled {
+		root.Handle("GET /metrics", apiMetrics.Handler())
+	}
+	root.Handle("/", tracing.Middleware(middleware.RequestLogger(slog.Default())(apiHandler)))
+
+	server := &http.Server{
+		Addr:              cfg.Server.Address,
+		Handler:           root,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	err = run(server, background, cfg.Server.ShutdownTimeout)
+
+	// Close the pool last, once no request or worker can be using it
+	pool.Close()
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if flushErr := shutdownTracing(flushCtx); flushErr != nil {
+		slog.Error("Failed to flush traces", "error", flushErr)
+	}
+
+	if err != nil {
+		exitWithError("Server stopped", err)
+	}
+}
+
+// newRateLimiter builds the limiter from config, warning about per route
+// budgets that don't match any route as they would have no effect
+func newRateLimiter(cfg config.RateLimitConfig, routes []route) *rateLimit.Limiter {
+	budget := func(b config.RateBudget) rateLimit.Limit {
+		return rateLimit.Limit{Rate: b.Rate, Burst: int(b.Burst)}
+	}
+
+	routeBudgets := make(map[string]rateLimit.Limit, len(cfg.Routes))
+	for name, b := range cfg.Routes {
+		if !slices.ContainsFunc(routes, func(r route) bool { return r.name == name }) {
+			slog.Warn("Rate limit configured for a route that isn't served", "route", name)
+		}
+		routeBudgets[name] = budget(b)
+	}
+
+	return rateLimit.New(budget(cfg.Read), budget(cfg.Write), routeBudgets, cfg.IdleTimeout)
+}
+
+// run serves until SIGINT or SIGTERM, then stops accepting connections, lets
+// in-flight requests finish and stops background workers, all within the
+// shutdown timeout. The database pool is closed by the caller afterwards.
+func run(server *http.Server, background *workers, shutdownTimeout time.Duration) error {
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "address", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		background.Stop(context.Background())
+		return err
+	case <-signals.Done():
+		stop()
+		slog.Info("Shutting down", "timeout", shutdownTimeout.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain connections: %w", err))
+	}
+
+	if err := background.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to stop background workers: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,478 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is read when CONFIG_FILE is not set, if it exists
+const DefaultFile = "config.yaml"
+
+// Config is the typed configuration for every command. Values are layered:
+// defaults, then the optional YAML file, then environment variables.
+type Config struct {
+	Server      ServerConfig      `yaml:"server"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Features    FeaturesConfig    `yaml:"features"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	RateLimit   RateLimitConfig   `yaml:"rateLimit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Dedupe      DedupeConfig      `yaml:"dedupe"`
+}
+
+type ServerConfig struct {
+	Address           string        `yaml:"address"`
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowedOrigins"`
+	AllowedHeaders []string `yaml:"allowedHeaders"`
+}
+
+type DatabaseConfig struct {
+	ConnectionString  string        `yaml:"connectionString"`
+	MaxConns          int32         `yaml:"maxConns"`
+	MinConns          int32         `yaml:"minConns"`
+	MaxConnLifetime   time.Duration `yaml:"maxConnLifetime"`
+	MaxConnIdleTime   time.Duration `yaml:"maxConnIdleTime"`
+	HealthCheckPeriod time.Duration `yaml:"healthCheckPeriod"`
+	ConnectTimeout    time.Duration `yaml:"connectTimeout"`
+}
+
+type FeaturesConfig struct {
+	Achievements bool `yaml:"achievements"`
+	Export       bool `yaml:"export"`
+	Import       bool `yaml:"import"`
+}
+
+type LoggingConfig struct {
+	// Level is one of debug, info, warn or error
+	Level string `yaml:"level"`
+	// Format is json for log aggregators or text for reading locally
+	Format string `yaml:"format"`
+}
+
+type MetricsConfig struct {
+	// Enabled serves Prometheus metrics at /metrics
+	Enabled bool `yaml:"enabled"`
+	// HouseholdRefreshInterval is how often the plant health gauges are
+	// recomputed, zero turns them off
+	HouseholdRefreshInterval time.Duration `yaml:"householdRefreshInterval"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, otlp to send spans over OTLP/HTTP, or stdout to print
+	// them for local debugging
+	Exporter string `yaml:"exporter"`
+	// OTLPEndpoint overrides the collector URL, otherwise the standard
+	// OTEL_EXPORTER_OTLP_* variables apply
+	OTLPEndpoint string `yaml:"otlpEndpoint"`
+	// SampleRatio is the fraction of new traces recorded, from 0 to 1
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+type RateLimitConfig struct {
+	// Enabled throttles API requests per client, identified by the remote IP
+	Enabled bool `yaml:"enabled"`
+	// Read is the budget shared by GET requests and Write the one shared by
+	// everything else
+	Read  RateBudget `yaml:"read"`
+	Write RateBudget `yaml:"write"`
+	// Routes gives routes a budget of their own, keyed by method and path
+	// without the version prefix, e.g. "POST /users/{userId}/plants/{plantId}/events"
+	Routes map[string]RateBudget `yaml:"routes"`
+	// IdleTimeout is how long a client is remembered after its last request
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+}
+
+type RateBudget struct {
+	// Rate is the sustained number of requests allowed per second
+	Rate float64 `yaml:"rate"`
+	// Burst is how many requests can be made at once after a quiet spell
+	Burst int32 `yaml:"burst"`
+}
+
+type IdempotencyConfig struct {
+	// Enabled lets POST requests send an Idempotency-Key header so retries
+	// of them are answered with the original response instead of repeated
+	Enabled bool `yaml:"enabled"`
+	// Retention is how long a key's response is kept for
+	Retention time.Duration `yaml:"retention"`
+	// CleanupInterval is how often expired keys are deleted
+	CleanupInterval time.Duration `yaml:"cleanupInterval"`
+}
+
+// DedupeConfig says how events of each type that repeat one recorded shortly
+// before, such as two housemates watering the same plant, are handled
+type DedupeConfig struct {
+	Water     DedupeRule `yaml:"water"`
+	Fertilize DedupeRule `yaml:"fertilize"`
+}
+
+type DedupeRule struct {
+	// Window is how recent an event must be for another to duplicate it,
+	// zero turns dedupe off for the type
+	Window time.Duration `yaml:"window"`
+	// Action is merge to add the duplicate's note and actor to the recent
+	// event, or reject to answer 409 with the recent event
+	Action string `yaml:"action"`
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Address:           ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "If-None-Match", "If-Modified-Since", "If-Match", "Idempotency-Key"},
+		},
+		Database: DatabaseConfig{
+			MaxConns:          10,
+			MinConns:          0,
+			MaxConnLifetime:   time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: time.Minute,
+			ConnectTimeout:    10 * time.Second,
+		},
+		Features: FeaturesConfig{
+			Achievements: true,
+			Export:       true,
+			Import:       true,
+		},
+		Logging: LoggingConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Metrics: MetricsConfig{
+			Enabled:                  true,
+			HouseholdRefreshInterval: time.Minute,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:     true,
+			Read:        RateBudget{Rate: 10, Burst: 50},
+			Write:       RateBudget{Rate: 1, Burst: 20},
+			IdleTimeout: 10 * time.Minute,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:         true,
+			Retention:       24 * time.Hour,
+			CleanupInterval: time.Hour,
+		},
+		Dedupe: DedupeConfig{
+			Water:     DedupeRule{Window: 30 * time.Minute, Action: "merge"},
+			Fertilize: DedupeRule{Window: 24 * time.Hour, Action: "reject"},
+		},
+	}
+}
+
+// Load builds the configuration and validates it, reporting every problem at once
+func Load() (Config, error) {
+	config := Default()
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = DefaultFile
+	}
+
+	if err := config.loadFile(path, explicit); err != nil {
+		return Config{}, err
+	}
+
+	if err := errors.Join(config.loadEnv(), config.Validate()); err != nil {
+		return Config{}, err
+	}
+
+	return config, nil
+}
+
+func (c *Config) loadFile(path string, required bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+type envVar struct {
+	apply func(value string) error
+	name  string
+}
+
+func (c *Config) loadEnv() error {
+	vars := []envVar{
+		{name: "LISTEN_ADDRESS", apply: setString(&c.Server.Address)},
+		{name: "SERVER_READ_TIMEOUT", apply: setDuration(&c.Server.ReadTimeout)},
+		{name: "SERVER_READ_HEADER_TIMEOUT", apply: setDuration(&c.Server.ReadHeaderTimeout)},
+		{name: "SERVER_WRITE_TIMEOUT", apply: setDuration(&c.Server.WriteTimeout)},
+		{name: "SERVER_IDLE_TIMEOUT", apply: setDuration(&c.Server.IdleTimeout)},
+		{name: "SERVER_SHUTDOWN_TIMEOUT", apply: setDuration(&c.Server.ShutdownTimeout)},
+		{name: "CORS_ALLOWED_ORIGINS", apply: setList(&c.CORS.AllowedOrigins)},
+		{name: "CORS_ALLOWED_HEADERS", apply: setList(&c.CORS.AllowedHeaders)},
+		{name: "DB_CONNECTION_STRING", apply: setString(&c.Database.ConnectionString)},
+		{name: "DB_MAX_CONNS", apply: setInt32(&c.Database.MaxConns)},
+		{name: "DB_MIN_CONNS", apply: setInt32(&c.Database.MinConns)},
+		{name: "DB_MAX_CONN_LIFETIME", apply: setDuration(&c.Database.MaxConnLifetime)},
+		{name: "DB_MAX_CONN_IDLE_TIME", apply: setDuration(&c.Database.MaxConnIdleTime)},
+		{name: "DB_HEALTH_CHECK_PERIOD", apply: setDuration(&c.Database.HealthCheckPeriod)},
+		{name: "DB_CONNECT_TIMEOUT", apply: setDuration(&c.Database.ConnectTimeout)},
+		{name: "FEATURE_ACHIEVEMENTS", apply: setBool(&c.Features.Achievements)},
+		{name: "FEATURE_EXPORT", apply: setBool(&c.Features.Export)},
+		{name: "FEATURE_IMPORT", apply: setBool(&c.Features.Import)},
+		{name: "LOG_LEVEL", apply: setString(&c.Logging.Level)},
+		{name: "LOG_FORMAT", apply: setString(&c.Logging.Format)},
+		{name: "METRICS_ENABLED", apply: setBool(&c.Metrics.Enabled)},
+		{name: "METRICS_HOUSEHOLD_REFRESH_INTERVAL", apply: setDuration(&c.Metrics.HouseholdRefreshInterval)},
+		{name: "TRACING_EXPORTER", apply: setString(&c.Tracing.Exporter)},
+		{name: "TRACING_OTLP_ENDPOINT", apply: setString(&c.Tracing.OTLPEndpoint)},
+		{name: "TRACING_SAMPLE_RATIO", apply: setFloat64(&c.Tracing.SampleRatio)},
+		{name: "RATE_LIMIT_ENABLED", apply: setBool(&c.RateLimit.Enabled)},
+		{name: "RATE_LIMIT_READ_RATE", apply: setFloat64(&c.RateLimit.Read.Rate)},
+		{name: "RATE_LIMIT_READ_BURST", apply: setInt32(&c.RateLimit.Read.Burst)},
+		{name: "RATE_LIMIT_WRITE_RATE", apply: setFloat64(&c.RateLimit.Write.Rate)},
+		{name: "RATE_LIMIT_WRITE_BURST", apply: setInt32(&c.RateLimit.Write.Burst)},
+		{name: "RATE_LIMIT_IDLE_TIMEOUT", apply: setDuration(&c.RateLimit.IdleTimeout)},
+		{name: "IDEMPOTENCY_ENABLED", apply: setBool(&c.Idempotency.Enabled)},
+		{name: "IDEMPOTENCY_RETENTION", apply: setDuration(&c.Idempotency.Retention)},
+		{name: "IDEMPOTENCY_CLEANUP_INTERVAL", apply: setDuration(&c.Idempotency.CleanupInterval)},
+		{name: "DEDUPE_WATER_WINDOW", apply: setDuration(&c.Dedupe.Water.Window)},
+		{name: "DEDUPE_WATER_ACTION", apply: setString(&c.Dedupe.Water.Action)},
+		{name: "DEDUPE_FERTILIZE_WINDOW", apply: setDuration(&c.Dedupe.Fertilize.Window)},
+		{name: "DEDUPE_FERTILIZE_ACTION", apply: setString(&c.Dedupe.Fertilize.Action)},
+	}
+
+	var errs []error
+	for _, v := range vars {
+		value, ok := os.LookupEnv(v.name)
+		if !ok {
+			continue
+		}
+
+		if err := v.apply(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", v.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks the configuration is usable, returning every problem found
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Address); err != nil {
+		invalid("server.address %q must be host:port (e.g. :8080)", c.Server.Address)
+	}
+
+	durations := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"server.readTimeout", c.Server.ReadTimeout},
+		{"server.readHeaderTimeout", c.Server.ReadHeaderTimeout},
+		{"server.writeTimeout", c.Server.WriteTimeout},
+		{"server.idleTimeout", c.Server.IdleTimeout},
+		{"server.shutdownTimeout", c.Server.ShutdownTimeout},
+		{"database.maxConnLifetime", c.Database.MaxConnLifetime},
+		{"database.maxConnIdleTime", c.Database.MaxConnIdleTime},
+		{"database.healthCheckPeriod", c.Database.HealthCheckPeriod},
+		{"database.connectTimeout", c.Database.ConnectTimeout},
+		{"metrics.householdRefreshInterval", c.Metrics.HouseholdRefreshInterval},
+		{"rateLimit.idleTimeout", c.RateLimit.IdleTimeout},
+		{"idempotency.retention", c.Idempotency.Retention},
+		{"idempotency.cleanupInterval", c.Idempotency.CleanupInterval},
+		{"dedupe.water.window", c.Dedupe.Water.Window},
+		{"dedupe.fertilize.window", c.Dedupe.Fertilize.Window},
+	}
+	for _, d := range durations {
+		if d.duration < 0 {
+			invalid("%s cannot be negative", d.name)
+		}
+	}
+
+	if c.Database.ConnectTimeout == 0 {
+		invalid("database.connectTimeout must be greater than zero")
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		invalid("cors.allowedOrigins must list at least one origin, use * to allow any")
+	}
+
+	if c.Database.ConnectionString == "" {
+		invalid("database.connectionString is required (or set DB_CONNECTION_STRING)")
+	}
+
+	if c.Database.MaxConns < 1 {
+		invalid("database.maxConns must be at least 1")
+	}
+
+	if c.Database.MinConns < 0 || c.Database.MinConns > c.Database.MaxConns {
+		invalid("database.minConns must be between 0 and database.maxConns")
+	}
+
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, c.Logging.Level) {
+		invalid("logging.level %q must be debug, info, warn or error", c.Logging.Level)
+	}
+
+	if !slices.Contains([]string{"json", "text"}, c.Logging.Format) {
+		invalid("logging.format %q must be json or text", c.Logging.Format)
+	}
+
+	if !slices.Contains([]string{"none", "otlp", "stdout"}, c.Tracing.Exporter) {
+		invalid("tracing.exporter %q must be none, otlp or stdout", c.Tracing.Exporter)
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sampleRatio must be between 0 and 1")
+	}
+
+	if c.RateLimit.Enabled {
+		names := []string{"rateLimit.read", "rateLimit.write"}
+		budgets := []RateBudget{c.RateLimit.Read, c.RateLimit.Write}
+		for _, route := range slices.Sorted(maps.Keys(c.RateLimit.Routes)) {
+			method, path, ok := strings.Cut(route, " ")
+			if !ok || method != strings.ToUpper(method) || !strings.HasPrefix(path, "/") {
+				invalid("rateLimit.routes key %q must be a method and path (e.g. \"POST /users/{id}/plants\")", route)
+			}
+			names = append(names, fmt.Sprintf("rateLimit.routes[%q]", route))
+			budgets = append(budgets, c.RateLimit.Routes[route])
+		}
+
+		for i, budget := range budgets {
+			if budget.Rate <= 0 {
+				invalid("%s.rate must be greater than zero", names[i])
+			}
+			if budget.Burst < 1 {
+				invalid("%s.burst must be at least 1", names[i])
+			}
+		}
+	}
+
+	if c.Idempotency.Enabled {
+		if c.Idempotency.Retention == 0 {
+			invalid("idempotency.retention must be greater than zero")
+		}
+		if c.Idempotency.CleanupInterval == 0 {
+			invalid("idempotency.cleanupInterval must be greater than zero")
+		}
+	}
+
+	dedupeRules := []struct {
+		name string
+		rule DedupeRule
+	}{
+		{"dedupe.water", c.Dedupe.Water},
+		{"dedupe.fertilize", c.Dedupe.Fertilize},
+	}
+	for _, d := range dedupeRules {
+		if !slices.Contains([]string{"merge", "reject"}, d.rule.Action) {
+			invalid("%s.action %q must be merge or reject", d.name, d.rule.Action)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func setString(target *string) func(string) error {
+	return func(value string) error {
+		*target = value
+		return nil
+	}
+}
+
+func setList(target *[]string) func(string) error {
+	return func(value string) error {
+		list := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*target = list
+		return nil
+	}
+}
+
+func setDuration(target *time.Duration) func(string) error {
+	return func(value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (e.g. 30s)", value)
+		}
+		*target = duration
+		return nil
+	}
+}
+
+func setInt32(target *int32) func(string) error {
+	return func(value string) error {
+		i, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		*target = int32(i)
+		return nil
+	}
+}
+
+func setFloat64(target *float64) func(string) error {
+	return func(value string) error {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		*target = f
+		return nil
+	}
+}
+
+func setBool(target *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q (use true or false)", value)
+		}
+		*target = b
+		return nil
+	}
+}
@@ -0,0 +1,64 @@
+package domainErrors
+
+import "errors"
+
+// Kind says what sort of failure an error is, independent of where it came
+// from, so the HTTP layer can pick a status without knowing about pgx or
+// individual services. Use errors.Is(err, domainErrors.NotFound) to test for one.
+type Kind string
+
+func (k Kind) Error() string {
+	return string(k)
+}
+
+const (
+	NotFound   Kind = "not found"
+	Conflict   Kind = "conflict"
+	Forbidden  Kind = "forbidden"
+	Validation Kind = "validation"
+	// PreconditionFailed is a write based on an out of date read
+	PreconditionFailed Kind = "precondition failed"
+)
+
+// Error is a failure of a known Kind. Message is safe to show to clients and
+// Field optionally names the request field at fault.
+type Error struct {
+	Kind    Kind
+	Message string
+	Field   string
+	Err     error
+}
+
+// New creates an error of kind with a client facing message
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+// Wrap creates an error of kind with a client facing message that keeps err
+// as its cause for logging and errors.Is
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the error's Kind as well as the error itself
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// As returns the *Error in err's chain, if there is one
+func As(err error) (*Error, bool) {
+	var domainError *Error
+	ok := errors.As(err, &domainError)
+	return domainError, ok
+}
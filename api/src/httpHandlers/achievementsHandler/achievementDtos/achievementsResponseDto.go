@@ -0,0 +1,55 @@
+package achievementDtos
+
+import (
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/services/achievementsService"
+)
+
+type AchievementsResponseDto struct {
+	Streaks      StreaksDto        `json:"streaks"`
+	Achievements []*AchievementDto `json:"achievements"`
+}
+
+type StreaksDto struct {
+	WaterDays     int `json:"waterDays"`
+	FertilizeDays int `json:"fertilizeDays"`
+}
+
+type AchievementDto struct {
+	EarnedAt    *time.Time `json:"earnedAt"`
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Earned      bool       `json:"earned"`
+}
+
+func FromServiceSummary(summary achievementsService.Summary) *AchievementsResponseDto {
+	achievementsDto := make([]*AchievementDto, len(summary.Achievements))
+	for i, achievement := range summary.Achievements {
+		achievementsDto[i] = FromServiceAchievement(achievement)
+	}
+
+	return &AchievementsResponseDto{
+		Streaks: StreaksDto{
+			WaterDays:     summary.WaterStreakDays,
+			FertilizeDays: summary.FertilizeStreakDays,
+		},
+		Achievements: achievementsDto,
+	}
+}
+
+func FromServiceAchievement(achievement achievementsService.Achievement) *AchievementDto {
+	response := &AchievementDto{
+		Code:        achievement.Code,
+		Name:        achievement.Name,
+		Description: achievement.Description,
+	}
+
+	if achievement.EarnedAt != (time.Time{}) {
+		response.EarnedAt = &achievement.EarnedAt
+		response.Earned = true
+	}
+
+	return response
+}
@@ -0,0 +1,37 @@
+package achievementsHandler
+
+import (
+	"net/http"
+
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/achievementsHandler/achievementDtos"
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/params"
+	"github.com/ReidMason/plant-tracker/src/services/achievementsService"
+)
+
+// achievementsHandler implements the HTTP handler for streaks and achievements
+type achievementsHandler struct {
+	achievementsService achievementsService.AchievementsService
+}
+
+// New creates a new achievements handler
+func New(achievementsService achievementsService.AchievementsService) *achievementsHandler {
+	return &achievementsHandler{
+		achievementsService: achievementsService,
+	}
+}
+
+// GetAchievements handles GET /users/{id}/achievements
+func (h *achievementsHandler) GetAchievements(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	summary, err := h.achievementsService.GetAchievements(r.Context(), userId)
+	if err != nil {
+		apiResponse.Error(w, r, err, "Failed to get achievements")
+		return
+	}
+	apiResponse.Ok(w, achievementDtos.FromServiceSummary(summary))
+}
@@ -0,0 +1,114 @@
+package conditional
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+)
+
+// ETag builds a strong entity tag from the parts that identify a version of a
+// resource, e.g. ETag("plant", id, updatedAt.UnixMicro())
+func ETag(parts ...any) string {
+	values := make([]string, len(parts))
+	for i, part := range parts {
+		values[i] = fmt.Sprint(part)
+	}
+	return `"` + strings.Join(values, "-") + `"`
+}
+
+// NotModified sets the ETag and, unless modified is zero, Last-Modified
+// validators on the response. If the request's If-None-Match or
+// If-Modified-Since shows the client already has this version it sends 304
+// and returns true, leaving the handler nothing more to do.
+//
+// Look the version up before the data it describes, so that a change in
+// between is caught by the client's next request instead of being missed.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+	// Caches may store responses but must check they are current before use
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if !fresh(r, etag, modified) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// fresh evaluates the preconditions in the order RFC 9110 gives: If-None-Match
+// wins, and If-Modified-Since is only used without it
+func fresh(r *http.Request, etag string, modified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return matchesAny(header, etag)
+	}
+
+	if header := r.Header.Get("If-Modified-Since"); header != "" && !modified.IsZero() {
+		since, err := http.ParseTime(header)
+		if err != nil {
+			return false
+		}
+		// HTTP dates only have whole seconds
+		return !modified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// matchesAny reports whether etag is in a comma separated If-None-Match
+// list, comparing weakly as that header calls for
+func matchesAny(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Match is what an If-Match header allows a write to change: any current
+// version, or only the listed ones
+type Match struct {
+	Any      bool
+	Versions []int64
+}
+
+// IfMatch reads If-Match on a write to a resource whose ETag starts with its
+// version number, e.g. ETag(version, modified.UnixMicro()). Only the version
+// is compared, so changes that don't bump it don't fail the write. A missing
+// header is answered with 428 and ok is false. Tags that aren't a version,
+// including weak ones, are left out as they can never match.
+func IfMatch(w http.ResponseWriter, r *http.Request) (match Match, ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		apiResponse.PreconditionRequired(w)
+		return Match{}, false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return Match{Any: true}, true
+		}
+
+		unquoted, quoted := strings.CutPrefix(candidate, `"`)
+		unquoted, closed := strings.CutSuffix(unquoted, `"`)
+		if !quoted || !closed {
+			continue
+		}
+		unquoted, _, _ = strings.Cut(unquoted, "-")
+		if version, err := strconv.ParseInt(unquoted, 10, 64); err == nil {
+			match.Versions = append(match.Versions, version)
+		}
+	}
+
+	return match, true
+}
@@ -0,0 +1,133 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	if etag := ETag(5, int64(1700000000000000)); etag != `"5-1700000000000000"` {
+		t.Errorf("ETag is %s, want \"5-1700000000000000\"", etag)
+	}
+	if etag := ETag("plant", 3); etag != `"plant-3"` {
+		t.Errorf("ETag is %s, want \"plant-3\"", etag)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2026, 10, 19, 10, 30, 0, 500_000_000, time.UTC)
+	etag := ETag(5, modified.UnixMicro())
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{name: "no validators", want: false},
+		{name: "matching tag", headers: map[string]string{"If-None-Match": etag}, want: true},
+		{name: "weak matching tag", headers: map[string]string{"If-None-Match": "W/" + etag}, want: true},
+		{name: "tag in a list", headers: map[string]string{"If-None-Match": `"4-1", ` + etag}, want: true},
+		{name: "any tag", headers: map[string]string{"If-None-Match": "*"}, want: true},
+		{name: "old tag", headers: map[string]string{"If-None-Match": `"4-1"`}, want: false},
+		{name: "same second", headers: map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, want: true},
+		{name: "later date", headers: map[string]string{"If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, want: true},
+		{name: "earlier date", headers: map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, want: false},
+		{name: "invalid date", headers: map[string]string{"If-Modified-Since": "yesterday"}, want: false},
+		{
+			name: "tag wins over date",
+			headers: map[string]string{
+				"If-None-Match":     `"4-1"`,
+				"If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat),
+			},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			for name, value := range test.headers {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+
+			if got := NotModified(w, r, etag, modified); got != test.want {
+				t.Fatalf("NotModified is %t, want %t", got, test.want)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag header is %q, want %q", got, etag)
+			}
+			if got := w.Header().Get("Last-Modified"); got != modified.Format(http.TimeFormat) {
+				t.Errorf("Last-Modified header is %q, want %q", got, modified.Format(http.TimeFormat))
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+				t.Errorf("Cache-Control header is %q, want no-cache", got)
+			}
+			if test.want && w.Code != http.StatusNotModified {
+				t.Errorf("status is %d, want 304", w.Code)
+			}
+		})
+	}
+}
+
+func TestNotModifiedWithoutDate(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-Modified-Since", time.Now().Format(http.TimeFormat))
+	w := httptest.NewRecorder()
+
+	if NotModified(w, r, ETag(1), time.Time{}) {
+		t.Error("If-Modified-Since matched a resource without a modified time")
+	}
+	if got := w.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("Last-Modified header is %q, want none", got)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   Match
+	}{
+		{name: "any", header: "*", want: Match{Any: true}},
+		{name: "version", header: `"5"`, want: Match{Versions: []int64{5}}},
+		{name: "version with modified time", header: `"5-1700000000000000"`, want: Match{Versions: []int64{5}}},
+		{name: "list", header: `"4", "5-1"`, want: Match{Versions: []int64{4, 5}}},
+		{name: "any in a list", header: `"4", *`, want: Match{Any: true}},
+		{name: "weak tag", header: `W/"5"`, want: Match{}},
+		{name: "unquoted", header: `5`, want: Match{}},
+		{name: "not a version", header: `"plant-5"`, want: Match{}},
+		{name: "invalid left out", header: `W/"4", "five", "6"`, want: Match{Versions: []int64{6}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/", nil)
+			r.Header.Set("If-Match", test.header)
+			w := httptest.NewRecorder()
+
+			match, ok := IfMatch(w, r)
+			if !ok {
+				t.Fatalf("IfMatch rejected %q with %d", test.header, w.Code)
+			}
+			if match.Any != test.want.Any || !slices.Equal(match.Versions, test.want.Versions) {
+				t.Errorf("match is %+v, want %+v", match, test.want)
+			}
+		})
+	}
+}
+
+func TestIfMatchRequiresHeader(t *testing.T) {
+	r := httptest.NewRequest("PUT", "/", nil)
+	w := httptest.NewRecorder()
+
+	if _, ok := IfMatch(w, r); ok {
+		t.Fatal("IfMatch allowed a write without If-Match")
+	}
+	if w.Code != http.StatusPreconditionRequired {
+		t.Errorf("status is %d, want 428", w.Code)
+	}
+}
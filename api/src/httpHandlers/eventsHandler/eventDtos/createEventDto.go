@@ -1,8 +1,29 @@
 package eventDtos
 
+import "github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
+
+// MaxNoteLength is the longest event note accepted, in characters
+const MaxNoteLength = 1000
+
 // CreateEventDto represents the data needed to create a new event
 type CreateEventDto struct {
 	EventType int32  `json:"eventType"`
 	Note      string `json:"note"`
 	PlantId   int    `json:"plantId"`
+	// ActorId is the user who did the care, when it wasn't the plant's owner
+	ActorId int64 `json:"actorId"`
+	// Force records the event even if the same care was recorded recently
+	Force bool `json:"force"`
+}
+
+func (d CreateEventDto) Validate() []validation.FieldError {
+	var checks validation.Checks
+	if d.EventType != 1 && d.EventType != 2 {
+		checks.Add("eventType", validation.CodeInvalid, "eventType must be 1 (water) or 2 (fertilize)")
+	}
+	checks.MaxLength("note", d.Note, MaxNoteLength)
+	if d.ActorId < 0 {
+		checks.Add("actorId", validation.CodeInvalid, "actorId must be a user id")
+	}
+	return checks.Errors()
 }
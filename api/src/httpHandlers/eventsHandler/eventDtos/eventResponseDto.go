@@ -12,6 +12,7 @@ type EventResponseDto struct {
 	Id        int64     `json:"id"`
 	PlantId   int64     `json:"plantId"`
 	TypeId    int32     `json:"typeId"`
+	ActorIds  []int64   `json:"actorIds"`
 }
 
 func FromStoreEvents(events []database.Event) []*EventResponseDto {
@@ -30,5 +31,6 @@ func FromStoreEvent(event database.Event) *EventResponseDto {
 		TypeId:    event.Eventtype,
 		Note:      event.Note,
 		Timestamp: event.Timestamp,
+		ActorIds:  event.Actorids,
 	}
 }
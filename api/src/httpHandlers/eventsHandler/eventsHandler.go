@@ -1,14 +1,14 @@
 package eventsHandler
 
 import (
-	"encoding/json"
-	"io"
+	"errors"
 	"net/http"
-	"strconv"
-	"strings"
 
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/conditional"
 	"github.com/ReidMason/plant-tracker/src/httpHandlers/eventsHandler/eventDtos"
 	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/params"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
 	"github.com/ReidMason/plant-tracker/src/services/eventsService"
 )
 
@@ -24,87 +24,80 @@ func New(eventsService eventsService.EventsService) *eventsHandler {
 	}
 }
 
-// ServeHTTP handles HTTP requests for events
-func (h *eventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
-
-	// Handle plant events (e.g. /users/{userId}/plants/{plantId}/events)
-	if strings.HasPrefix(path, "/users/") && strings.Contains(path, "/plants/") && strings.HasSuffix(path, "/events") {
-		h.handlePlantEvents(w, r)
+// GetEvents handles GET /users/{userId}/plants/{plantId}/events
+func (h *eventsHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "userId")
+	if !ok {
 		return
 	}
 
-	// If we get here, no route matched
-	http.Error(w, "Not found", http.StatusNotFound)
-}
-
-// handlePlantEvents handles requests for events related to a specific plant
-func (h *eventsHandler) handlePlantEvents(w http.ResponseWriter, r *http.Request) {
-	// Extract plant ID from path
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 6 {
-		apiResponse.NotFound(w)
+	plantId, ok := params.ID(w, r, "plantId")
+	if !ok {
 		return
 	}
 
-	// Get plant ID from the URL
-	plantIDStr := parts[4]
-	plantId, err := strconv.Atoi(plantIDStr)
+	modified, err := h.eventsService.GetEventsVersion(r.Context(), userId, plantId)
 	if err != nil {
-		apiResponse.NotFound(w)
+		apiResponse.Error(w, r, err, "Failed to get events")
 		return
 	}
 
-	ctx := r.Context()
-	switch r.Method {
-	case "GET":
-		// Get events for the plant
-		events, err := h.eventsService.GetEventsByPlantId(ctx, int64(plantId))
-		if err != nil {
-			apiResponse.InternalServerError[any](w, []string{"Failed to get events"})
-			return
-		}
-		apiResponse.Ok(w, eventDtos.FromStoreEvents(events))
-	case "POST":
-		// Create a new event for the plant
-		h.handleCreateEvent(w, r, plantId)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if conditional.NotModified(w, r, conditional.ETag("events", plantId, modified.UnixMicro()), modified) {
+		return
 	}
-}
 
-// handleCreateEvent handles requests to create a new event
-func (h *eventsHandler) handleCreateEvent(w http.ResponseWriter, r *http.Request, plantId int) {
-	// Read request body
-	body, err := io.ReadAll(r.Body)
+	// Get events for the plant
+	events, err := h.eventsService.GetEventsByPlantId(r.Context(), userId, plantId)
 	if err != nil {
-		apiResponse.InternalServerError[any](w, []string{"Failed to read request body"})
+		apiResponse.Error(w, r, err, "Failed to get events")
 		return
 	}
-	defer r.Body.Close()
+	apiResponse.Ok(w, eventDtos.FromStoreEvents(events))
+}
 
-	// Parse request body
-	var createEventDto eventDtos.CreateEventDto
-	err = json.Unmarshal(body, &createEventDto)
-	if err != nil {
-		apiResponse.BadRequest[any](w, []string{"Failed to parse request body"})
+// CreateEvent handles POST /users/{userId}/plants/{plantId}/events
+func (h *eventsHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "userId")
+	if !ok {
 		return
 	}
 
-	// Set the plant ID from the URL parameter
-	createEventDto.PlantId = plantId
+	plantId, ok := params.ID(w, r, "plantId")
+	if !ok {
+		return
+	}
 
-	// Validate event type
-	if createEventDto.EventType != 1 && createEventDto.EventType != 2 {
-		apiResponse.BadRequest[any](w, []string{"Invalid event type"})
+	var createEventDto eventDtos.CreateEventDto
+	if err := validation.Decode(w, r, &createEventDto); err != nil {
+		apiResponse.Invalid(w, err)
 		return
 	}
 
+	// Set the plant ID from the URL parameter
+	createEventDto.PlantId = int(plantId)
+
 	// Create event
 	ctx := r.Context()
-	newEvent, err := h.eventsService.CreateEvent(ctx, int64(createEventDto.PlantId), createEventDto.EventType, createEventDto.Note)
+	newEvent, merged, err := h.eventsService.CreateEvent(ctx, userId, int64(createEventDto.PlantId), eventsService.NewEvent{
+		Type:    createEventDto.EventType,
+		Note:    createEventDto.Note,
+		ActorId: createEventDto.ActorId,
+		Force:   createEventDto.Force,
+	})
 	if err != nil {
-		apiResponse.InternalServerError[any](w, []string{"Failed to create event"})
+		// Send the recent event back so the client can show who beat them to it
+		var duplicate *eventsService.DuplicateError
+		if errors.As(err, &duplicate) {
+			apiResponse.Conflict(w, eventDtos.FromStoreEvent(duplicate.Event), []string{duplicate.Error()})
+			return
+		}
+		apiResponse.Error(w, r, err, "Failed to create event")
+		return
+	}
+
+	// A duplicate merged into a recent event didn't create anything
+	if merged {
+		apiResponse.Ok(w, eventDtos.FromStoreEvent(newEvent))
 		return
 	}
 
@@ -0,0 +1,84 @@
+package exportHandler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/params"
+	"github.com/ReidMason/plant-tracker/src/logging"
+	"github.com/ReidMason/plant-tracker/src/services/exportService"
+)
+
+// exportHandler implements the HTTP handler for account exports
+type exportHandler struct {
+	exportService exportService.ExportService
+}
+
+// New creates a new export handler
+func New(exportService exportService.ExportService) *exportHandler {
+	return &exportHandler{
+		exportService: exportService,
+	}
+}
+
+// Export handles GET /users/{id}/export?format=json|csv
+func (h *exportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	format, err := exportService.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		apiResponse.BadRequest[any](w, []string{err.Error()})
+		return
+	}
+
+	// Large histories can take longer to stream than the server's write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	attachment := &attachmentWriter{
+		w:        w,
+		format:   format,
+		filename: fmt.Sprintf("plant-tracker-%d-%s", userId, time.Now().Format("20060102")),
+	}
+
+	err = h.exportService.Export(r.Context(), userId, format, attachment)
+	if err == nil {
+		return
+	}
+
+	if attachment.started {
+		// The status has already been sent so all we can do is cut the download short
+		logging.FromContext(r.Context()).Error("Failed to export user data", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	apiResponse.Error(w, r, err, "Failed to export user data")
+}
+
+// attachmentWriter sets the download headers on the first write so that errors
+// raised before any data is produced can still be reported as JSON
+type attachmentWriter struct {
+	w        http.ResponseWriter
+	format   exportService.Format
+	filename string
+	started  bool
+}
+
+func (a *attachmentWriter) Write(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+		contentType, extension := "application/json", "json"
+		if a.format == exportService.FormatCSV {
+			contentType, extension = "application/zip", "zip"
+		}
+		a.w.Header().Set("Content-Type", contentType)
+		a.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, a.filename, extension))
+		a.w.WriteHeader(http.StatusOK)
+	}
+
+	return a.w.Write(p)
+}
@@ -0,0 +1,133 @@
+package healthHandler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+)
+
+// Pinger checks the database can be reached
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// SchemaVersion reports the migration the database is at
+type SchemaVersion func(ctx context.Context) (int64, error)
+
+// BuildInfo identifies the running binary
+type BuildInfo struct {
+	Version string
+	Commit  string
+	// LatestSchemaVersion is the newest migration embedded in the binary
+	LatestSchemaVersion int64
+}
+
+// checkTimeout stops a hung database from hanging the orchestrator's probes
+const checkTimeout = 2 * time.Second
+
+// healthHandler implements the liveness, readiness and version endpoints
+type healthHandler struct {
+	db            Pinger
+	schemaVersion SchemaVersion
+	build         BuildInfo
+	startedAt     time.Time
+}
+
+// New creates a new health handler
+func New(db Pinger, schemaVersion SchemaVersion, build BuildInfo) *healthHandler {
+	return &healthHandler{
+		db:            db,
+		schemaVersion: schemaVersion,
+		build:         build,
+		startedAt:     time.Now(),
+	}
+}
+
+// Register adds the health endpoints to mux
+func (h *healthHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.handleHealthz)
+	mux.HandleFunc("GET /readyz", h.handleReadyz)
+	mux.HandleFunc("GET /version", h.handleVersion)
+}
+
+type statusDto struct {
+	Status string `json:"status"`
+}
+
+type readinessDto struct {
+	Checks map[string]string `json:"checks"`
+	Status string            `json:"status"`
+}
+
+type versionDto struct {
+	StartedAt           time.Time `json:"startedAt"`
+	Version             string    `json:"version"`
+	Commit              string    `json:"commit"`
+	SchemaVersion       int64     `json:"schemaVersion"`
+	LatestSchemaVersion int64     `json:"latestSchemaVersion"`
+	UptimeSeconds       int64     `json:"uptimeSeconds"`
+}
+
+// handleHealthz reports that the process is up, without touching the database
+func (h *healthHandler) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	apiResponse.Ok(w, statusDto{Status: "ok"})
+}
+
+// handleReadyz reports whether the database is reachable and fully migrated
+func (h *healthHandler) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	readiness := readinessDto{Status: "ok", Checks: map[string]string{}}
+	problems := make([]string, 0)
+
+	if err := h.db.Ping(ctx); err != nil {
+		readiness.Checks["database"] = "unreachable"
+		problems = append(problems, fmt.Sprintf("database: %s", err))
+	} else {
+		readiness.Checks["database"] = "ok"
+	}
+
+	current, err := h.schemaVersion(ctx)
+	latest := h.build.LatestSchemaVersion
+	switch {
+	case err != nil:
+		readiness.Checks["migrations"] = "unknown"
+		problems = append(problems, fmt.Sprintf("migrations: %s", err))
+	case current < latest:
+		readiness.Checks["migrations"] = "pending"
+		problems = append(problems, fmt.Sprintf("migrations: database is at version %d, latest is %d", current, latest))
+	default:
+		readiness.Checks["migrations"] = "ok"
+	}
+
+	if len(problems) > 0 {
+		readiness.Status = "unavailable"
+		apiResponse.ServiceUnavailable(w, readiness, problems)
+		return
+	}
+	apiResponse.Ok(w, readiness)
+}
+
+func (h *healthHandler) handleVersion(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+
+	response := versionDto{
+		Version:             h.build.Version,
+		Commit:              h.build.Commit,
+		StartedAt:           h.startedAt,
+		UptimeSeconds:       int64(time.Since(h.startedAt).Seconds()),
+		LatestSchemaVersion: h.build.LatestSchemaVersion,
+	}
+
+	// The schema version is informational so a database outage isn't an error here
+	if current, err := h.schemaVersion(ctx); err == nil {
+		response.SchemaVersion = current
+	}
+
+	apiResponse.Ok(w, response)
+}
@@ -0,0 +1,38 @@
+package importDtos
+
+import (
+	"github.com/ReidMason/plant-tracker/src/services/exportService"
+	"github.com/ReidMason/plant-tracker/src/services/importService"
+)
+
+type ImportReportDto struct {
+	PlantsCreated   []ImportedPlantDto `json:"plantsCreated"`
+	PlantsExisting  []ImportedPlantDto `json:"plantsExisting"`
+	EventsCreated   int                `json:"eventsCreated"`
+	EventsDuplicate int                `json:"eventsDuplicate"`
+	DryRun          bool               `json:"dryRun"`
+}
+
+// ImportedPlantDto has no ID for plants a dry run would create
+type ImportedPlantDto struct {
+	Name string `json:"name"`
+	Id   int64  `json:"id,omitempty"`
+}
+
+func FromServiceReport(report importService.Report) *ImportReportDto {
+	return &ImportReportDto{
+		PlantsCreated:   fromPlantRecords(report.PlantsCreated),
+		PlantsExisting:  fromPlantRecords(report.PlantsExisting),
+		EventsCreated:   report.EventsCreated,
+		EventsDuplicate: report.EventsDuplicate,
+		DryRun:          report.DryRun,
+	}
+}
+
+func fromPlantRecords(records []exportService.PlantRecord) []ImportedPlantDto {
+	plants := make([]ImportedPlantDto, 0, len(records))
+	for _, record := range records {
+		plants = append(plants, ImportedPlantDto{Id: record.Id, Name: record.Name})
+	}
+	return plants
+}
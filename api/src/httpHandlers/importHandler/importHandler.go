@@ -0,0 +1,112 @@
+package importHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/importHandler/importDtos"
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/params"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
+	"github.com/ReidMason/plant-tracker/src/services/exportService"
+	"github.com/ReidMason/plant-tracker/src/services/importService"
+)
+
+// maxUploadSize is the largest upload accepted, which bounds the temporary
+// files it can spill to
+const maxUploadSize = 32 << 20
+
+// maxUploadMemory limits how much of an upload is buffered in memory before
+// spilling to a temporary file
+const maxUploadMemory = 8 << 20
+
+// importHandler implements the HTTP handler for importing plants and events
+type importHandler struct {
+	importService importService.ImportService
+}
+
+// New creates a new import handler
+func New(importService importService.ImportService) *importHandler {
+	return &importHandler{
+		importService: importService,
+	}
+}
+
+// Import handles multipart uploads to POST /users/{id}/import?format=json|csv&dryRun=true
+// with the file in the "file" field and an optional JSON column mapping in "mapping"
+func (h *importHandler) Import(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	format, err := exportService.ParseFormat(query.Get("format"))
+	if err != nil {
+		apiResponse.BadRequest[any](w, []string{err.Error()})
+		return
+	}
+
+	dryRun := false
+	if value := query.Get("dryRun"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			apiResponse.BadRequest[any](w, []string{"dryRun must be true or false"})
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			apiResponse.Invalid(w, &validation.Error{Status: http.StatusRequestEntityTooLarge, Fields: []validation.FieldError{
+				{Field: "file", Code: validation.CodeTooLarge, Message: "Uploads must be at most 32 MiB"},
+			}})
+			return
+		}
+		apiResponse.BadRequest[any](w, []string{"Expected a multipart form upload"})
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		apiResponse.BadRequest[any](w, []string{"File is required"})
+		return
+	}
+	defer file.Close()
+
+	var mapping importService.Mapping
+	if value := r.FormValue("mapping"); value != "" {
+		if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+			apiResponse.BadRequest[any](w, []string{"Failed to parse mapping"})
+			return
+		}
+	}
+
+	report, err := h.importService.Import(r.Context(), int64(userId), importService.Request{
+		File:    file,
+		Size:    fileHeader.Size,
+		Format:  format,
+		Mapping: mapping,
+		DryRun:  dryRun,
+	})
+	if err != nil {
+		var validationError *importService.ValidationError
+		if errors.As(err, &validationError) {
+			apiResponse.BadRequest[any](w, validationError.Problems)
+			return
+		}
+		apiResponse.Error(w, r, err, "Failed to import data")
+		return
+	}
+
+	if dryRun {
+		apiResponse.Ok(w, importDtos.FromServiceReport(report))
+		return
+	}
+	apiResponse.Created(w, importDtos.FromServiceReport(report))
+}
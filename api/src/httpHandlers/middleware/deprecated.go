@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Deprecated marks responses as coming from a deprecated path. Deprecation
+// (RFC 9745) and Sunset (RFC 8594) give the dates it was deprecated and will
+// be removed, and a successor-version link points at the same path under
+// successorPrefix.
+func Deprecated(deprecatedAt time.Time, sunset time.Time, successorPrefix string) func(http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunsetDate)
+			w.Header().Add("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, successorPrefix, r.URL.EscapedPath()))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
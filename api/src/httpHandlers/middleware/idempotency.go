@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
+	"github.com/ReidMason/plant-tracker/src/logging"
+	"github.com/ReidMason/plant-tracker/src/services/idempotencyService"
+)
+
+// maxIdempotencyKeyLength is the longest Idempotency-Key accepted
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodySize is the largest body a request with an Idempotency-Key
+// can have, as it is read into memory to be compared with repeats
+const maxIdempotentBodySize = 32 << 20
+
+// Idempotency makes requests with an Idempotency-Key header safe to retry.
+// The first request with a key is handled and its response stored, repeats of
+// it get that response again with Idempotent-Replayed: true, and reusing the
+// key for a different request is rejected with 422. Keys are scoped to the
+// client, identified the same way as for rate limiting, so it must wrap the
+// route's handler. Requests without the header are handled as usual.
+func Idempotency(service idempotencyService.IdempotencyService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Idempotency-Key")
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, ok := idempotencyKey(header)
+			if !ok {
+				apiResponse.BadRequest[any](w, []string{"Idempotency-Key must be 1 to 255 printable characters"})
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			if err != nil {
+				var maxBytesError *http.MaxBytesError
+				if errors.As(err, &maxBytesError) {
+					apiResponse.Invalid(w, &validation.Error{Status: http.StatusRequestEntityTooLarge, Fields: []validation.FieldError{
+						{Code: validation.CodeTooLarge, Message: "Requests with an Idempotency-Key must be at most 32 MiB"},
+					}})
+					return
+				}
+				apiResponse.BadRequest[any](w, []string{"Failed to read request body"})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			client := requestClient(r)
+			requestHash := hashRequest(r, body)
+
+			replay, err := service.Begin(ctx, client, key, requestHash)
+			if err != nil {
+				apiResponse.Error(w, r, err, "Failed to check Idempotency-Key")
+				return
+			}
+			if replay != nil {
+				for name, values := range replay.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(replay.Status)
+				w.Write(replay.Body)
+				return
+			}
+
+			recorder := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// The key is stored even if the client has gone, as it may retry,
+			// but server errors are forgotten so a retry is handled again
+			storeCtx := context.WithoutCancel(ctx)
+			if recorder.status >= http.StatusInternalServerError {
+				if err := service.Release(storeCtx, client, key); err != nil {
+					logging.FromContext(ctx).Error("Failed to release Idempotency-Key", "error", err)
+				}
+			} else {
+				response := idempotencyService.Response{Status: recorder.status, Header: recorder.header, Body: recorder.body.Bytes()}
+				if err := service.Complete(storeCtx, client, key, response); err != nil {
+					logging.FromContext(ctx).Error("Failed to store Idempotency-Key response", "error", err)
+				}
+			}
+
+			for name, values := range recorder.header {
+				w.Header()[name] = values
+			}
+			w.WriteHeader(recorder.status)
+			w.Write(recorder.body.Bytes())
+		})
+	}
+}
+
+// idempotencyKey reads the key from the header, which may be sent as a
+// quoted string as the IETF draft for it specifies
+func idempotencyKey(header string) (string, bool) {
+	key := strings.TrimSpace(header)
+	if len(key) >= 2 && strings.HasPrefix(key, `"`) && strings.HasSuffix(key, `"`) {
+		key = key[1 : len(key)-1]
+	}
+
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return "", false
+	}
+	for _, c := range key {
+		if c < ' ' || c > '~' {
+			return "", false
+		}
+	}
+	return key, true
+}
+
+// hashRequest identifies a request by what it asks for, so a key sent again
+// for a different route or body can be told apart from a retry
+func hashRequest(r *http.Request, body []byte) []byte {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hash.Sum(nil)
+}
+
+// responseRecorder holds a response back so it can be stored before it is sent
+type responseRecorder struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status = status
+	r.wroteHeader = true
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(b)
+}
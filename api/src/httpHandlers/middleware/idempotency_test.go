@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ReidMason/plant-tracker/src/services/idempotencyService"
+)
+
+// fakeIdempotency keeps keys in memory the way the service keeps them in the
+// database: claimed by Begin and either given a response or forgotten
+type fakeIdempotency struct {
+	hashes    map[string][]byte
+	responses map[string]*idempotencyService.Response
+	completed int
+	released  int
+}
+
+func newFakeIdempotency() *fakeIdempotency {
+	return &fakeIdempotency{
+		hashes:    map[string][]byte{},
+		responses: map[string]*idempotencyService.Response{},
+	}
+}
+
+func (f *fakeIdempotency) Begin(ctx context.Context, client string, key string, requestHash []byte) (*idempotencyService.Response, error) {
+	id := client + " " + key
+	hash, ok := f.hashes[id]
+	if !ok {
+		f.hashes[id] = requestHash
+		return nil, nil
+	}
+	if !bytes.Equal(hash, requestHash) {
+		return nil, idempotencyService.ErrKeyReused
+	}
+	if f.responses[id] == nil {
+		return nil, idempotencyService.ErrKeyInProgress
+	}
+	return f.responses[id], nil
+}
+
+func (f *fakeIdempotency) Complete(ctx context.Context, client string, key string, response idempotencyService.Response) error {
+	f.completed++
+	f.responses[client+" "+key] = &response
+	return nil
+}
+
+func (f *fakeIdempotency) Release(ctx context.Context, client string, key string) error {
+	f.released++
+	delete(f.hashes, client+" "+key)
+	return nil
+}
+
+// countingHandler answers with status and counts how often it was called
+func countingHandler(status int, calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d,"body":%s}`, *calls, body)
+	})
+}
+
+func idempotentRequest(key string, body string) *http.Request {
+	r := httptest.NewRequest("POST", "/users/1/plants", strings.NewReader(body))
+	r.RemoteAddr = "10.0.0.1:51234"
+	if key != "" {
+		r.Header.Set("Idempotency-Key", key)
+	}
+	return r
+}
+
+func TestIdempotencyReplays(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "created", status: http.StatusCreated},
+		{name: "client error", status: http.StatusUnprocessableEntity},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := newFakeIdempotency()
+			calls := 0
+			handler := Idempotency(service)(countingHandler(test.status, &calls))
+
+			first := httptest.NewRecorder()
+			handler.ServeHTTP(first, idempotentRequest("abc", `{"name":"Fern"}`))
+			second := httptest.NewRecorder()
+			handler.ServeHTTP(second, idempotentRequest("abc", `{"name":"Fern"}`))
+
+			if calls != 1 {
+				t.Errorf("handler was called %d times, want 1", calls)
+			}
+			if service.completed != 1 || service.released != 0 {
+				t.Errorf("completed %d and released %d keys, want 1 and 0", service.completed, service.released)
+			}
+			if first.Header().Get("Idempotent-Replayed") != "" {
+				t.Error("first response is marked as replayed")
+			}
+			if second.Header().Get("Idempotent-Replayed") != "true" {
+				t.Error("repeated response isn't marked as replayed")
+			}
+			if second.Code != test.status || first.Code != test.status {
+				t.Errorf("statuses are %d and %d, want %d", first.Code, second.Code, test.status)
+			}
+			if second.Body.String() != first.Body.String() {
+				t.Errorf("replayed body is %s, want %s", second.Body.String(), first.Body.String())
+			}
+			if second.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("replayed Content-Type is %q, want application/json", second.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestIdempotencyReleasesServerErrors(t *testing.T) {
+	service := newFakeIdempotency()
+	calls := 0
+	handler := Idempotency(service)(countingHandler(http.StatusInternalServerError, &calls))
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, idempotentRequest("abc", `{}`))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("status is %d, want 500", w.Code)
+		}
+		if w.Header().Get("Idempotent-Replayed") != "" {
+			t.Error("retry of a server error was replayed")
+		}
+	}
+
+	if calls != 2 {
+		t.Errorf("handler was called %d times, want a retry after a server error to be handled again", calls)
+	}
+	if service.released != 2 || service.completed != 0 {
+		t.Errorf("completed %d and released %d keys, want 0 and 2", service.completed, service.released)
+	}
+}
+
+func TestIdempotencyRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		begin      func(service *fakeIdempotency)
+		key        string
+		wantStatus int
+	}{
+		{
+			name: "key reused for another request",
+			begin: func(service *fakeIdempotency) {
+				handler := Idempotency(service)(countingHandler(http.StatusCreated, new(int)))
+				handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("abc", `{"name":"Fern"}`))
+			},
+			key:        "abc",
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "key still in progress",
+			begin: func(service *fakeIdempotency) {
+				service.hashes["ip:10.0.0.1 abc"] = hashRequest(idempotentRequest("abc", ""), []byte(`{"name":"Cactus"}`))
+			},
+			key:        "abc",
+			wantStatus: http.StatusConflict,
+		},
+		{name: "key too long", key: strings.Repeat("a", maxIdempotencyKeyLength+1), wantStatus: http.StatusBadRequest},
+		{name: "key not printable", key: "abc\tdef", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := newFakeIdempotency()
+			if test.begin != nil {
+				test.begin(service)
+			}
+			calls := 0
+			handler := Idempotency(service)(countingHandler(http.StatusCreated, &calls))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, idempotentRequest(test.key, `{"name":"Cactus"}`))
+
+			if w.Code != test.wantStatus {
+				t.Errorf("status is %d, want %d", w.Code, test.wantStatus)
+			}
+			if calls != 0 {
+				t.Errorf("handler was called %d times, want 0", calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	service := newFakeIdempotency()
+	calls := 0
+	handler := Idempotency(service)(countingHandler(http.StatusCreated, &calls))
+
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), idempotentRequest("", `{}`))
+	}
+
+	if calls != 2 {
+		t.Errorf("handler was called %d times, want 2", calls)
+	}
+	if len(service.hashes) != 0 || service.completed != 0 {
+		t.Error("a request without a key was stored")
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{header: "abc", want: "abc", ok: true},
+		{header: "  abc  ", want: "abc", ok: true},
+		{header: `"abc"`, want: "abc", ok: true},
+		{header: `"a b"`, want: "a b", ok: true},
+		{header: `""`, ok: false},
+		{header: `"`, want: `"`, ok: true},
+		{header: strings.Repeat("a", maxIdempotencyKeyLength), want: strings.Repeat("a", maxIdempotencyKeyLength), ok: true},
+		{header: strings.Repeat("a", maxIdempotencyKeyLength+1), ok: false},
+		{header: "café", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.header, func(t *testing.T) {
+			key, ok := idempotencyKey(test.header)
+			if ok != test.ok || key != test.want {
+				t.Errorf("key is %q, %t, want %q, %t", key, ok, test.want, test.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// RequestObserver records the outcome of served requests
+type RequestObserver interface {
+	ObserveRequest(method string, route string, status int, duration time.Duration)
+}
+
+// Metrics reports every request to observer once it has been served. It must
+// wrap the mux so that the matched route pattern is known afterwards.
+func Metrics(observer RequestObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := &statusRecorder{ResponseWriter: w}
+			start := time.Now()
+
+			defer func() {
+				status := recorder.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+				observer.ObserveRequest(r.Method, r.Pattern, status, time.Since(start))
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+	"github.com/ReidMason/plant-tracker/src/rateLimit"
+)
+
+// RateLimiter decides whether a client may make another request to a route
+type RateLimiter interface {
+	Allow(route string, method string, client string) rateLimit.Decision
+}
+
+// RateLimit throttles requests to one route, answering 429 with Retry-After
+// once the client's budget is spent. Every response reports the budget in
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers. It must
+// wrap the route's handler so the path parameters used to identify the
+// client are known.
+func RateLimit(limiter RateLimiter, route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			decision := limiter.Allow(route, r.Method, requestClient(r))
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			w.Header().Set("RateLimit-Reset", ceilSeconds(decision.Reset))
+
+			if !decision.Allowed {
+				w.Header().Set("Retry-After", ceilSeconds(decision.RetryAfter))
+				apiResponse.TooManyRequests(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestClient identifies who is making a request by its remote IP alone.
+// Nothing authenticates bearer tokens or the user in the path yet, so keying
+// on either would let a client get a fresh budget, and a new bucket, by
+// changing it on every request.
+func requestClient(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		authorization string
+		pathValues    map[string]string
+		want          string
+	}{
+		{name: "remote IP", remoteAddr: "10.0.0.1:51234", want: "ip:10.0.0.1"},
+		{name: "IPv6", remoteAddr: "[::1]:51234", want: "ip:::1"},
+		{name: "no port", remoteAddr: "10.0.0.1", want: "ip:10.0.0.1"},
+		{name: "user", remoteAddr: "10.0.0.1:51234", pathValues: map[string]string{"id": "7"}, want: "ip:10.0.0.1"},
+		{name: "user with plant", remoteAddr: "10.0.0.1:51234", pathValues: map[string]string{"userId": "7", "plantId": "3"}, want: "ip:10.0.0.1"},
+		{name: "bearer token", remoteAddr: "10.0.0.1:51234", authorization: "Bearer abc", want: "ip:10.0.0.1"},
+		{name: "bearer token with user", remoteAddr: "10.0.0.1:51234", authorization: "Bearer abc", pathValues: map[string]string{"id": "7"}, want: "ip:10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = test.remoteAddr
+			if test.authorization != "" {
+				r.Header.Set("Authorization", test.authorization)
+			}
+			for name, value := range test.pathValues {
+				r.SetPathValue(name, value)
+			}
+
+			if client := requestClient(r); client != test.want {
+				t.Errorf("client is %q, want %q", client, test.want)
+			}
+		})
+	}
+}
+
+func TestRequestClientIgnoresUnauthenticatedIdentities(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer first")
+	r.SetPathValue("id", "1")
+	first := requestClient(r)
+
+	r.Header.Set("Authorization", "Bearer second")
+	r.SetPathValue("id", "2")
+	if second := requestClient(r); first != second {
+		t.Errorf("changing the bearer token and user changed the client from %q to %q", first, second)
+	}
+}
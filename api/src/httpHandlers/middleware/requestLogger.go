@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength stops clients from stuffing arbitrary data into our logs
+const maxRequestIDLength = 128
+
+// RequestLogger assigns each request an ID, or keeps the one the client sent,
+// puts a logger tagged with it on the request context and logs the outcome of
+// every request once it has been served
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestId := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestId) {
+				requestId = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestId)
+
+			requestLogger := logger.With("requestId", requestId)
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+				requestLogger = requestLogger.With("traceId", spanContext.TraceID().String())
+			}
+			r = r.WithContext(logging.WithLogger(r.Context(), requestLogger))
+			recorder := &statusRecorder{ResponseWriter: w}
+			start := time.Now()
+
+			defer func() {
+				status := recorder.status
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				level := slog.LevelInfo
+				switch {
+				case status >= 500:
+					level = slog.LevelError
+				case status >= 400:
+					level = slog.LevelWarn
+				}
+
+				attributes := []any{
+					"method", r.Method,
+					"path", r.URL.Path,
+					"route", r.Pattern,
+					"status", status,
+					"bytes", recorder.bytes,
+					"durationMs", time.Since(start).Milliseconds(),
+				}
+				if userId := requestUserID(r); userId != "" {
+					attributes = append(attributes, "userId", userId)
+				}
+				requestLogger.Log(r.Context(), level, "request", attributes...)
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+// requestUserID reads the user from the matched route, which the mux records
+// on the request it was given
+func requestUserID(r *http.Request) string {
+	if userId := r.PathValue("userId"); userId != "" {
+		return userId
+	}
+	return r.PathValue("id")
+}
+
+func validRequestID(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range requestId {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 12)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// statusRecorder captures the status and size of a response for logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+)
+
+// routeMethods are the methods probed for when building an Allow header
+var routeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// RouteErrors serves mux, answering requests it has no route for in the API's
+// JSON envelope instead of the mux's plain text: 405 with an Allow header when
+// the path is served for other methods, otherwise 404.
+func RouteErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		if allowed := allowedMethods(mux, r); len(allowed) > 0 {
+			apiResponse.MethodNotAllowed(w, allowed)
+			return
+		}
+		apiResponse.NotFound(w)
+	})
+}
+
+func allowedMethods(mux *http.ServeMux, r *http.Request) []string {
+	allowed := make([]string, 0)
+	for _, method := range routeMethods {
+		probe := r.WithContext(r.Context())
+		probe.Method = method
+		if _, pattern := mux.Handler(probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
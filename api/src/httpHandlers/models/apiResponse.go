@@ -3,11 +3,17 @@ package apiResponse
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
+	"github.com/ReidMason/plant-tracker/src/logging"
 )
 
 type apiResponse[T any] struct {
-	Data   T        `json:"data"`
-	Errors []string `json:"errors"`
+	Data        T                       `json:"data"`
+	Errors      []string                `json:"errors"`
+	FieldErrors []validation.FieldError `json:"fieldErrors,omitempty"`
 }
 
 func createResponse[T any](data T) apiResponse[T] {
@@ -18,42 +24,112 @@ func createErrorResponse(errors []string) apiResponse[any] {
 	return apiResponse[any]{Errors: errors}
 }
 
-func writeResponse[T any](w http.ResponseWriter, response apiResponse[T]) {
+func writeResponse[T any](w http.ResponseWriter, status int, response apiResponse[T]) {
 	responseJson, err := json.Marshal(response)
 	if err != nil {
-		InternalServerError[any](w, []string{"Failed to marshal response"})
-		return
+		status = http.StatusInternalServerError
+		responseJson, _ = json.Marshal(createErrorResponse([]string{"Failed to marshal response"}))
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	w.Write(responseJson)
 }
 
 func Ok[T any](w http.ResponseWriter, data T) {
-	w.WriteHeader(http.StatusOK)
 	response := createResponse(data)
-	writeResponse(w, response)
+	writeResponse(w, http.StatusOK, response)
 }
 
 func InternalServerError[T any](w http.ResponseWriter, errors []string) {
-	w.WriteHeader(http.StatusInternalServerError)
 	response := createErrorResponse(errors)
-	writeResponse(w, response)
+	writeResponse(w, http.StatusInternalServerError, response)
 }
 
 func NotFound(w http.ResponseWriter) {
-	w.WriteHeader(http.StatusNotFound)
 	response := createErrorResponse([]string{"Resource not found"})
-	writeResponse(w, response)
+	writeResponse(w, http.StatusNotFound, response)
+}
+
+// MethodNotAllowed lists the methods the resource does support in the Allow header
+func MethodNotAllowed(w http.ResponseWriter, allowed []string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	response := createErrorResponse([]string{"Method not allowed"})
+	writeResponse(w, http.StatusMethodNotAllowed, response)
+}
+
+// TooManyRequests is sent once a client has used up its rate limit
+func TooManyRequests(w http.ResponseWriter) {
+	response := createErrorResponse([]string{"Too many requests, try again later"})
+	writeResponse(w, http.StatusTooManyRequests, response)
 }
 
 func BadRequest[T any](w http.ResponseWriter, errors []string) {
-	w.WriteHeader(http.StatusBadRequest)
 	response := createErrorResponse(errors)
-	writeResponse(w, response)
+	writeResponse(w, http.StatusBadRequest, response)
+}
+
+// Invalid reports a request body that couldn't be decoded or failed
+// validation, with a field error per problem alongside the usual messages
+func Invalid(w http.ResponseWriter, err *validation.Error) {
+	response := apiResponse[any]{Errors: err.Messages(), FieldErrors: err.Fields}
+	writeResponse(w, err.Status, response)
+}
+
+// domainStatuses maps each kind of domain error to the status it is reported with
+var domainStatuses = map[domainErrors.Kind]int{
+	domainErrors.NotFound:           http.StatusNotFound,
+	domainErrors.Conflict:           http.StatusConflict,
+	domainErrors.Forbidden:          http.StatusForbidden,
+	domainErrors.Validation:         http.StatusUnprocessableEntity,
+	domainErrors.PreconditionFailed: http.StatusPreconditionFailed,
+}
+
+// Error reports a failed service call. Domain errors are sent with their
+// status and message, anything else is logged and sent as a 500 with message.
+func Error(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if domainError, ok := domainErrors.As(err); ok {
+		if status, ok := domainStatuses[domainError.Kind]; ok {
+			response := createErrorResponse([]string{domainError.Message})
+			if domainError.Kind == domainErrors.Validation {
+				response.FieldErrors = []validation.FieldError{
+					{Field: domainError.Field, Code: validation.CodeInvalid, Message: domainError.Message},
+				}
+			}
+			writeResponse(w, status, response)
+			return
+		}
+	}
+
+	logging.FromContext(r.Context()).Error(message, "error", err)
+	InternalServerError[any](w, []string{message})
 }
 
 func Created[T any](w http.ResponseWriter, data T) {
-	w.WriteHeader(http.StatusCreated)
 	response := createResponse(data)
-	writeResponse(w, response)
+	writeResponse(w, http.StatusCreated, response)
+}
+
+// NoContent is sent for successful requests with nothing to return, such as deletes
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreconditionRequired is sent for writes that must say which version they
+// expect to change with If-Match but didn't
+func PreconditionRequired(w http.ResponseWriter) {
+	response := createErrorResponse([]string{"If-Match is required, send the ETag from the latest read"})
+	writeResponse(w, http.StatusPreconditionRequired, response)
+}
+
+// Conflict is sent for requests that clash with existing data, with that
+// data so the client can show it
+func Conflict[T any](w http.ResponseWriter, data T, errors []string) {
+	response := apiResponse[T]{Data: data, Errors: errors}
+	writeResponse(w, http.StatusConflict, response)
+}
+
+func ServiceUnavailable[T any](w http.ResponseWriter, data T, errors []string) {
+	response := apiResponse[T]{Data: data, Errors: errors}
+	writeResponse(w, http.StatusServiceUnavailable, response)
 }
@@ -0,0 +1,20 @@
+package params
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+)
+
+// ID parses the named path value as a database ID. A malformed ID is answered
+// with a 400 and ok is false, so the handler only has to return.
+func ID(w http.ResponseWriter, r *http.Request, name string) (id int64, ok bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id < 1 {
+		apiResponse.BadRequest[any](w, []string{fmt.Sprintf("Invalid %s, expected a positive whole number", name)})
+		return 0, false
+	}
+	return id, true
+}
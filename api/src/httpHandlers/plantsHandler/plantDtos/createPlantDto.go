@@ -1,6 +1,18 @@
 package plantDtos
 
+import "github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
+
+// MaxNameLength is the longest plant name accepted, in characters
+const MaxNameLength = 100
+
 type CreatePlantDto struct {
 	Name   string `json:"name"`
 	UserId int    `json:"userId"`
 }
+
+func (d CreatePlantDto) Validate() []validation.FieldError {
+	var checks validation.Checks
+	checks.Required("name", d.Name)
+	checks.MaxLength("name", d.Name, MaxNameLength)
+	return checks.Errors()
+}
@@ -0,0 +1,28 @@
+package plantDtos
+
+import "github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
+
+// PatchPlantDto is a JSON merge patch of a plant. Fields left out are
+// unchanged.
+type PatchPlantDto struct {
+	Name *string `json:"name"`
+
+	nulls []string
+}
+
+func (d *PatchPlantDto) UnmarshalJSON(data []byte) error {
+	type fields PatchPlantDto
+	nulls, err := validation.DecodePatch(data, (*fields)(d))
+	d.nulls = nulls
+	return err
+}
+
+func (d PatchPlantDto) Validate() []validation.FieldError {
+	var checks validation.Checks
+	checks.NotNull("name", d.nulls)
+	if d.Name != nil {
+		checks.Required("name", *d.Name)
+		checks.MaxLength("name", *d.Name, MaxNameLength)
+	}
+	return checks.Errors()
+}
@@ -15,6 +15,7 @@ type PlantResponseDto struct {
 	NextFertilizerDue   *time.Time                  `json:"nextFertilizerDue"`
 	Name                string                      `json:"name"`
 	Id                  int64                       `json:"id"`
+	Version             int64                       `json:"version"`
 }
 
 func FromStorePlants(plants []database.Plant) []*PlantResponseDto {
@@ -37,15 +38,16 @@ func FromServicePlants(plants []plantsService.Plant) []*PlantResponseDto {
 
 func FromServicePlant(plant plantsService.Plant) *PlantResponseDto {
 	response := &PlantResponseDto{
-		Id:   plant.Id,
-		Name: plant.Name,
+		Id:      plant.Id,
+		Name:    plant.Name,
+		Version: plant.Version,
 	}
 
-	if plant.LatestWaterEvent != (database.Event{}) {
+	if plant.LatestWaterEvent.ID != 0 {
 		response.LastWaterEvent = eventDtos.FromStoreEvent(plant.LatestWaterEvent)
 	}
 
-	if plant.LatestFertilizerEvent != (database.Event{}) {
+	if plant.LatestFertilizerEvent.ID != 0 {
 		response.LastFertilizerEvent = eventDtos.FromStoreEvent(plant.LatestFertilizerEvent)
 	}
 
@@ -62,7 +64,8 @@ func FromServicePlant(plant plantsService.Plant) *PlantResponseDto {
 
 func FromStorePlant(plant database.Plant) *PlantResponseDto {
 	return &PlantResponseDto{
-		Id:   plant.ID,
-		Name: plant.Name,
+		Id:      plant.ID,
+		Name:    plant.Name,
+		Version: plant.Version,
 	}
 }
@@ -0,0 +1,14 @@
+package plantDtos
+
+import "github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
+
+type UpdatePlantDto struct {
+	Name string `json:"name"`
+}
+
+func (d UpdatePlantDto) Validate() []validation.FieldError {
+	var checks validation.Checks
+	checks.Required("name", d.Name)
+	checks.MaxLength("name", d.Name, MaxNameLength)
+	return checks.Errors()
+}
@@ -1,15 +1,14 @@
 package plantsHandler
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"strconv"
-	"strings"
+	"time"
 
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/conditional"
 	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/params"
 	"github.com/ReidMason/plant-tracker/src/httpHandlers/plantsHandler/plantDtos"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
 	"github.com/ReidMason/plant-tracker/src/services/plantsService"
 )
 
@@ -23,140 +22,185 @@ func New(plantsService plantsService.GetPlantsService) *plantsHandler {
 	}
 }
 
-func (p *plantsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Path
+// GetPlants handles GET /users/{id}/plants
+func (p *plantsHandler) GetPlants(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "id")
+	if !ok {
+		return
+	}
 
-	// Handle user plants collection (e.g. /users/1/plants)
-	if strings.HasPrefix(path, "/users/") && strings.HasSuffix(path, "/plants") && !strings.Contains(path, "/plants/") {
-		p.handleUserPlants(w, r)
+	version, err := p.plantsService.GetPlantsVersion(r.Context(), userId)
+	if err != nil {
+		apiResponse.Error(w, r, err, "Failed to get plants")
 		return
 	}
 
-	// Handle specific plant for user (e.g. /users/1/plants/2)
-	if strings.HasPrefix(path, "/users/") && strings.Contains(path, "/plants/") && !strings.Contains(path, "/events") {
-		p.handleSingleUserPlant(w, r)
+	// Deleting a plant doesn't move the latest change time, so lists only
+	// get an ETag, which includes the count, and no Last-Modified
+	etag := conditional.ETag("plants", userId, version.PlantCount, version.UpdatedAt.UnixMicro())
+	if conditional.NotModified(w, r, etag, time.Time{}) {
 		return
 	}
 
-	// If no patterns match, return 404
-	http.Error(w, "Not found", http.StatusNotFound)
+	plants, err := p.plantsService.GetPlantsByUserId(r.Context(), userId)
+	if err != nil {
+		apiResponse.Error(w, r, err, "Failed to get plants")
+		return
+	}
+	apiResponse.Ok(w, plantDtos.FromServicePlants(plants))
 }
 
-func (p *plantsHandler) handleUserPlants(w http.ResponseWriter, r *http.Request) {
-	// Extract user ID from path
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 3 {
-		apiResponse.NotFound(w)
+// GetPlant handles GET /users/{userId}/plants/{plantId}
+func (p *plantsHandler) GetPlant(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "userId")
+	if !ok {
+		return
+	}
+
+	plantId, ok := params.ID(w, r, "plantId")
+	if !ok {
 		return
 	}
 
-	userIDStr := parts[2]
-	userId, err := strconv.Atoi(userIDStr)
+	version, err := p.plantsService.GetPlantVersion(r.Context(), userId, plantId)
 	if err != nil {
-		apiResponse.NotFound(w)
+		apiResponse.Error(w, r, err, "Failed to get plant")
 		return
 	}
 
-	ctx := r.Context()
-	switch r.Method {
-	case "GET":
-		plants, err := p.plantsService.GetPlantsByUserId(ctx, int64(userId))
-		if err != nil {
-			apiResponse.InternalServerError[any](w, []string{"Failed to get plant"})
-			return
-		}
-		apiResponse.Ok(w, plantDtos.FromServicePlants(plants))
-	case "POST":
-		p.handleCreatePlant(w, r, userId)
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	if conditional.NotModified(w, r, plantETag(version.Number, version.Modified), version.Modified) {
+		return
+	}
+
+	plant, err := p.plantsService.GetPlantById(r.Context(), userId, plantId)
+	if err != nil {
+		apiResponse.Error(w, r, err, "Failed to get plant")
+		return
 	}
+	apiResponse.Ok(w, plantDtos.FromServicePlant(plant))
 }
 
-func (p *plantsHandler) handleSingleUserPlant(w http.ResponseWriter, r *http.Request) {
-	// Extract IDs from path (/users/{userId}/plants/{plantId})
-	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) < 5 {
-		apiResponse.NotFound(w)
+// UpdatePlant handles PUT /users/{userId}/plants/{plantId}
+func (p *plantsHandler) UpdatePlant(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "userId")
+	if !ok {
 		return
 	}
 
-	// Extract plantId (we don't need userId for this operation)
-	plantIDStr := parts[4]
+	plantId, ok := params.ID(w, r, "plantId")
+	if !ok {
+		return
+	}
 
-	plantId, err := strconv.Atoi(plantIDStr)
-	if err != nil {
-		apiResponse.NotFound(w)
+	match, ok := conditional.IfMatch(w, r)
+	if !ok {
 		return
 	}
 
-	ctx := r.Context()
-	switch r.Method {
-	case "GET":
-		plant, err := p.plantsService.GetPlantById(ctx, int64(plantId))
-		if err != nil {
-			apiResponse.InternalServerError[any](w, []string{"Failed to get plant"})
-			return
-		}
-
-		if plant.Id == 0 {
-			apiResponse.NotFound(w)
-			return
-		}
-		apiResponse.Ok(w, plantDtos.FromServicePlant(plant))
-	case "PUT":
-		// Parse body for new name
-		var req struct {
-			Name string `json:"name"`
-		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
-			apiResponse.BadRequest[any](w, []string{"Invalid request body"})
-			return
-		}
-		updatedPlant, err := p.plantsService.UpdatePlant(ctx, int64(plantId), req.Name)
-		if err != nil {
-			apiResponse.InternalServerError[any](w, []string{"Failed to update plant"})
-			return
-		}
-		apiResponse.Ok(w, plantDtos.FromServicePlant(updatedPlant))
-	case "DELETE":
-		fmt.Fprintln(w, "DELETE /users/{userId}/plants/{plantId}")
-	default:
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	var req plantDtos.UpdatePlantDto
+	if err := validation.Decode(w, r, &req); err != nil {
+		apiResponse.Invalid(w, err)
+		return
+	}
+	updatedPlant, err := p.plantsService.UpdatePlant(r.Context(), userId, plantId, precondition(match), req.Name)
+	if err != nil {
+		apiResponse.Error(w, r, err, "Failed to update plant")
+		return
 	}
+	w.Header().Set("ETag", plantETag(updatedPlant.Version, updatedPlant.Modified))
+	apiResponse.Ok(w, plantDtos.FromServicePlant(updatedPlant))
 }
 
-func (p *plantsHandler) handleCreatePlant(w http.ResponseWriter, r *http.Request, userId int) {
-	// Read request body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		apiResponse.InternalServerError[any](w, []string{"Failed to read request body"})
+// PatchPlant handles PATCH /users/{userId}/plants/{plantId}, taking a JSON
+// merge patch of the plant's fields
+func (p *plantsHandler) PatchPlant(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "userId")
+	if !ok {
 		return
 	}
-	defer r.Body.Close()
 
-	// Parse request body
-	var createPlantDto plantDtos.CreatePlantDto
-	err = json.Unmarshal(body, &createPlantDto)
+	plantId, ok := params.ID(w, r, "plantId")
+	if !ok {
+		return
+	}
+
+	match, ok := conditional.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req plantDtos.PatchPlantDto
+	if err := validation.Decode(w, r, &req); err != nil {
+		apiResponse.Invalid(w, err)
+		return
+	}
+	patchedPlant, err := p.plantsService.PatchPlant(r.Context(), userId, plantId, precondition(match), plantsService.Patch{
+		Name: req.Name,
+	})
 	if err != nil {
-		apiResponse.BadRequest[any](w, []string{"Failed to parse request body"})
+		apiResponse.Error(w, r, err, "Failed to update plant")
+		return
+	}
+	w.Header().Set("ETag", plantETag(patchedPlant.Version, patchedPlant.Modified))
+	apiResponse.Ok(w, plantDtos.FromServicePlant(patchedPlant))
+}
+
+// DeletePlant handles DELETE /users/{userId}/plants/{plantId}
+func (p *plantsHandler) DeletePlant(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "userId")
+	if !ok {
 		return
 	}
 
-	// Validate request
-	if createPlantDto.Name == "" {
-		apiResponse.BadRequest[any](w, []string{"Name is required"})
+	plantId, ok := params.ID(w, r, "plantId")
+	if !ok {
+		return
+	}
+
+	match, ok := conditional.IfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := p.plantsService.DeletePlant(r.Context(), userId, plantId, precondition(match)); err != nil {
+		apiResponse.Error(w, r, err, "Failed to delete plant")
+		return
+	}
+	apiResponse.NoContent(w)
+}
+
+// plantETag starts with the version so it can be sent back in If-Match to
+// update the plant, and includes when it was modified so that care recorded
+// for it, which doesn't change the version, still changes the ETag
+func plantETag(version int64, modified time.Time) string {
+	return conditional.ETag(version, modified.UnixMicro())
+}
+
+func precondition(match conditional.Match) plantsService.Precondition {
+	return plantsService.Precondition{Any: match.Any, Versions: match.Versions}
+}
+
+// CreatePlant handles POST /users/{id}/plants
+func (p *plantsHandler) CreatePlant(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var createPlantDto plantDtos.CreatePlantDto
+	if err := validation.Decode(w, r, &createPlantDto); err != nil {
+		apiResponse.Invalid(w, err)
 		return
 	}
 
 	// Set the userId from the URL parameter
-	createPlantDto.UserId = userId
+	createPlantDto.UserId = int(userId)
 
 	ctx := r.Context()
 	// Create plant
 	newPlant, err := p.plantsService.CreatePlant(ctx, createPlantDto.Name, int64(createPlantDto.UserId))
 	if err != nil {
-		apiResponse.InternalServerError[any](w, []string{"Failed to create plant"})
+		apiResponse.Error(w, r, err, "Failed to create plant")
 		return
 	}
 
@@ -0,0 +1,64 @@
+package statDtos
+
+import (
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/services/statsService"
+)
+
+type StatsResponseDto struct {
+	Water     IntervalStatsDto `json:"water"`
+	Fertilize IntervalStatsDto `json:"fertilize"`
+	Weekly    []BucketDto      `json:"weekly"`
+	Monthly   []BucketDto      `json:"monthly"`
+}
+
+type IntervalStatsDto struct {
+	EventCount       int64   `json:"eventCount"`
+	IntervalCount    int64   `json:"intervalCount"`
+	MeanDays         float64 `json:"meanDays"`
+	MedianDays       float64 `json:"medianDays"`
+	LongestGapDays   float64 `json:"longestGapDays"`
+	OnTimePercentage float64 `json:"onTimePercentage"`
+	ScheduleDays     int     `json:"scheduleDays"`
+}
+
+type BucketDto struct {
+	Start          time.Time `json:"start"`
+	WaterCount     int64     `json:"waterCount"`
+	FertilizeCount int64     `json:"fertilizeCount"`
+}
+
+func FromServiceStats(stats statsService.Stats) *StatsResponseDto {
+	return &StatsResponseDto{
+		Water:     fromServiceIntervalStats(stats.Water),
+		Fertilize: fromServiceIntervalStats(stats.Fertilize),
+		Weekly:    fromServiceBuckets(stats.Weekly),
+		Monthly:   fromServiceBuckets(stats.Monthly),
+	}
+}
+
+func fromServiceIntervalStats(stats statsService.IntervalStats) IntervalStatsDto {
+	return IntervalStatsDto{
+		EventCount:       stats.EventCount,
+		IntervalCount:    stats.IntervalCount,
+		MeanDays:         stats.MeanDays,
+		MedianDays:       stats.MedianDays,
+		LongestGapDays:   stats.LongestGapDays,
+		OnTimePercentage: stats.OnTimePercentage,
+		ScheduleDays:     stats.ScheduleDays,
+	}
+}
+
+func fromServiceBuckets(buckets []statsService.Bucket) []BucketDto {
+	bucketsDto := make([]BucketDto, len(buckets))
+	for i, bucket := range buckets {
+		bucketsDto[i] = BucketDto{
+			Start:          bucket.Start,
+			WaterCount:     bucket.WaterCount,
+			FertilizeCount: bucket.FertilizeCount,
+		}
+	}
+
+	return bucketsDto
+}
@@ -0,0 +1,57 @@
+package statsHandler
+
+import (
+	"net/http"
+
+	apiResponse "github.com/ReidMason/plant-tracker/src/httpHandlers/models"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/params"
+	"github.com/ReidMason/plant-tracker/src/httpHandlers/statsHandler/statDtos"
+	"github.com/ReidMason/plant-tracker/src/services/statsService"
+)
+
+// statsHandler implements the HTTP handler for care statistics
+type statsHandler struct {
+	statsService statsService.StatsService
+}
+
+// New creates a new stats handler
+func New(statsService statsService.StatsService) *statsHandler {
+	return &statsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetUserStats handles GET /users/{id}/stats
+func (h *statsHandler) GetUserStats(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	stats, err := h.statsService.GetUserStats(r.Context(), userId)
+	if err != nil {
+		apiResponse.Error(w, r, err, "Failed to get stats")
+		return
+	}
+	apiResponse.Ok(w, statDtos.FromServiceStats(stats))
+}
+
+// GetPlantStats handles GET /users/{userId}/plants/{plantId}/stats
+func (h *statsHandler) GetPlantStats(w http.ResponseWriter, r *http.Request) {
+	userId, ok := params.ID(w, r, "userId")
+	if !ok {
+		return
+	}
+
+	plantId, ok := params.ID(w, r, "plantId")
+	if !ok {
+		return
+	}
+
+	stats, err := h.statsService.GetPlantStats(r.Context(), userId, plantId)
+	if err != nil {
+		apiResponse.Error(w, r, err, "Failed to get stats")
+		return
+	}
+	apiResponse.Ok(w, statDtos.FromServiceStats(stats))
+}
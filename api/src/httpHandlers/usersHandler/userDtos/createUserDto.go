@@ -1,5 +1,17 @@
 package userDtos
 
+import "github.com/ReidMason/plant-tracker/src/httpHandlers/validation"
+
+// MaxNameLength is the longest user name accepted, in characters
+const MaxNameLength = 50
+
 type CreateUserDto struct {
 	Name string `json:"name"`
 }
+
+func (d CreateUserDto) Validate() []validation.FieldError {
+	var checks validation.Checks
+	checks.Required("name", d.Name)
+	checks.MaxLength("name", d.Name, MaxNameLength)
+	return checks.Errors()
+}
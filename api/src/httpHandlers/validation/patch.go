@@ -0,0 +1,48 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+)
+
+// DecodePatch decodes a JSON merge patch (RFC 7396) into dst, rejecting
+// unknown fields like Decode does. Patch DTOs have pointer fields so fields
+// left out, which mean leave unchanged, stay nil, but so do fields set to
+// null, which mean remove. DecodePatch tells them apart by returning the
+// names of the null ones.
+//
+// Call it from the DTO's UnmarshalJSON, decoding into a type without that
+// method to avoid recursing.
+func DecodePatch(data []byte, dst any) (nulls []string, err error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	// A patch that isn't an object would replace the whole resource
+	if fields == nil {
+		return nil, &json.UnmarshalTypeError{Value: "null", Type: reflect.TypeOf(dst)}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return nil, err
+	}
+
+	for name, value := range fields {
+		if string(value) == "null" {
+			nulls = append(nulls, name)
+		}
+	}
+	slices.Sort(nulls)
+	return nulls, nil
+}
+
+// NotNull checks a patch doesn't remove a field that is required
+func (c *Checks) NotNull(field string, nulls []string) {
+	if slices.Contains(nulls, field) {
+		c.Add(field, CodeRequired, field+" can't be removed")
+	}
+}
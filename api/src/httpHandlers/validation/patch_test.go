@@ -0,0 +1,107 @@
+package validation
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+type testPatch struct {
+	Name  *string `json:"name"`
+	Notes *string `json:"notes"`
+	Count *int    `json:"count"`
+}
+
+func TestDecodePatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantName  string
+		wantNulls []string
+	}{
+		{name: "empty patch", body: `{}`},
+		{name: "set field", body: `{"name": "Fern"}`, wantName: "Fern"},
+		{name: "remove field", body: `{"notes": null}`, wantNulls: []string{"notes"}},
+		{name: "nulls sorted", body: `{"notes": null, "count": null, "name": "Fern"}`, wantName: "Fern", wantNulls: []string{"count", "notes"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var patch testPatch
+			nulls, err := DecodePatch([]byte(test.body), &patch)
+			if err != nil {
+				t.Fatalf("DecodePatch failed: %v", err)
+			}
+			if !slices.Equal(nulls, test.wantNulls) {
+				t.Errorf("nulls are %v, want %v", nulls, test.wantNulls)
+			}
+
+			name := ""
+			if patch.Name != nil {
+				name = *patch.Name
+			}
+			if name != test.wantName {
+				t.Errorf("name is %q, want %q", name, test.wantName)
+			}
+			if slices.Contains(nulls, "notes") && patch.Notes != nil {
+				t.Error("notes was removed but decoded to a value")
+			}
+		})
+	}
+}
+
+func TestDecodePatchRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantField  string
+	}{
+		{name: "null patch", body: `null`, wantStatus: http.StatusBadRequest, wantCode: CodeMalformed},
+		{name: "array patch", body: `[{"name": "Fern"}]`, wantStatus: http.StatusBadRequest, wantCode: CodeMalformed},
+		{name: "string patch", body: `"Fern"`, wantStatus: http.StatusBadRequest, wantCode: CodeMalformed},
+		{name: "malformed", body: `{"name": `, wantStatus: http.StatusBadRequest, wantCode: CodeMalformed},
+		{name: "unknown field", body: `{"colour": "green"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeUnknownField, wantField: "colour"},
+		{name: "wrong type", body: `{"count": "three"}`, wantStatus: http.StatusUnprocessableEntity, wantCode: CodeInvalidType, wantField: "count"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var patch testPatch
+			_, err := DecodePatch([]byte(test.body), &patch)
+			if err == nil {
+				t.Fatalf("DecodePatch accepted %s", test.body)
+			}
+
+			// Handlers get these errors through Decode, which maps them the same way
+			decoded := decodeError(err)
+			if decoded.Status != test.wantStatus {
+				t.Errorf("status is %d, want %d", decoded.Status, test.wantStatus)
+			}
+			if len(decoded.Fields) != 1 {
+				t.Fatalf("got %d field errors, want 1", len(decoded.Fields))
+			}
+			if field := decoded.Fields[0]; field.Code != test.wantCode || field.Field != test.wantField {
+				t.Errorf("field error is %+v, want code %q on %q", field, test.wantCode, test.wantField)
+			}
+		})
+	}
+}
+
+func TestNotNull(t *testing.T) {
+	var checks Checks
+	checks.NotNull("name", []string{"notes"})
+	if errors := checks.Errors(); len(errors) != 0 {
+		t.Fatalf("removing another field gave %v", errors)
+	}
+
+	checks.NotNull("name", []string{"name", "notes"})
+	errors := checks.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("got %d field errors, want 1", len(errors))
+	}
+	if errors[0].Field != "name" || errors[0].Code != CodeRequired {
+		t.Errorf("field error is %+v, want code %q on name", errors[0], CodeRequired)
+	}
+}
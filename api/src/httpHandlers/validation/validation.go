@@ -0,0 +1,170 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxBodySize is the largest JSON request body accepted
+const MaxBodySize = 1 << 20
+
+// Codes identify what is wrong with a field so clients can pick their own wording
+const (
+	CodeRequired     = "required"
+	CodeTooLong      = "too_long"
+	CodeInvalid      = "invalid"
+	CodeInvalidType  = "invalid_type"
+	CodeUnknownField = "unknown_field"
+	CodeMalformed    = "malformed"
+	CodeTooLarge     = "too_large"
+)
+
+// FieldError describes one problem with a request. Field is the JSON name of
+// the offending field, or empty when the problem is with the body as a whole.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Validator is implemented by request DTOs to check their decoded values
+type Validator interface {
+	Validate() []FieldError
+}
+
+// Error is returned when a request body can't be decoded or fails validation.
+// Status is 400 for bodies that aren't JSON, 413 for bodies over MaxBodySize
+// and 422 for JSON with invalid fields.
+type Error struct {
+	Fields []FieldError
+	Status int
+}
+
+func (e *Error) Error() string {
+	return strings.Join(e.Messages(), ", ")
+}
+
+// Messages lists every problem as a human readable sentence
+func (e *Error) Messages() []string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Message
+	}
+	return messages
+}
+
+// Decode reads the request body as a single JSON object into dst, rejecting
+// unknown fields and oversized bodies, and then validates it
+func Decode(w http.ResponseWriter, r *http.Request, dst Validator) *Error {
+	body := http.MaxBytesReader(w, r.Body, MaxBodySize)
+	defer body.Close()
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return decodeError(err)
+		}
+		return &Error{Status: http.StatusBadRequest, Fields: []FieldError{
+			{Code: CodeMalformed, Message: "Request body must contain a single JSON object"},
+		}}
+	}
+
+	if fields := dst.Validate(); len(fields) > 0 {
+		return &Error{Status: http.StatusUnprocessableEntity, Fields: fields}
+	}
+
+	return nil
+}
+
+func decodeError(err error) *Error {
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	var maxBytesError *http.MaxBytesError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return &Error{Status: http.StatusBadRequest, Fields: []FieldError{
+			{Code: CodeRequired, Message: "Request body is required"},
+		}}
+	case errors.As(err, &maxBytesError):
+		return &Error{Status: http.StatusRequestEntityTooLarge, Fields: []FieldError{
+			{Code: CodeTooLarge, Message: fmt.Sprintf("Request body must be at most %d bytes", maxBytesError.Limit)},
+		}}
+	case errors.As(err, &typeError) && typeError.Field != "":
+		return &Error{Status: http.StatusUnprocessableEntity, Fields: []FieldError{
+			{Field: typeError.Field, Code: CodeInvalidType, Message: fmt.Sprintf("%s must be a %s", typeError.Field, jsonTypeName(typeError.Type.Kind().String()))},
+		}}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &Error{Status: http.StatusUnprocessableEntity, Fields: []FieldError{
+			{Field: field, Code: CodeUnknownField, Message: fmt.Sprintf("%s is not a known field", field)},
+		}}
+	case errors.As(err, &syntaxError), errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &typeError):
+		return &Error{Status: http.StatusBadRequest, Fields: []FieldError{
+			{Code: CodeMalformed, Message: "Request body must be a JSON object"},
+		}}
+	default:
+		return &Error{Status: http.StatusBadRequest, Fields: []FieldError{
+			{Code: CodeMalformed, Message: "Failed to read request body"},
+		}}
+	}
+}
+
+func jsonTypeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"):
+		return "whole number"
+	case strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "list"
+	case kind == "struct", kind == "map":
+		return "object"
+	default:
+		return kind
+	}
+}
+
+// Checks collects field errors from a DTO's Validate method
+type Checks struct {
+	fields []FieldError
+}
+
+// Add records a problem with field
+func (c *Checks) Add(field string, code string, message string) {
+	c.fields = append(c.fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Required checks a text field isn't empty or only whitespace
+func (c *Checks) Required(field string, value string) {
+	if strings.TrimSpace(value) == "" {
+		c.Add(field, CodeRequired, fmt.Sprintf("%s is required", field))
+	}
+}
+
+// MaxLength checks a text field has at most max characters
+func (c *Checks) MaxLength(field string, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		c.Add(field, CodeTooLong, fmt.Sprintf("%s must be at most %d characters", field, max))
+	}
+}
+
+// Errors returns the problems found, or nil if there were none
+func (c *Checks) Errors() []FieldError {
+	return c.fields
+}
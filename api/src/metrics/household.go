@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/logging"
+	"github.com/ReidMason/plant-tracker/src/services/plantsService"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	metricsStore "github.com/ReidMason/plant-tracker/src/stores/metricsStore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Household exports gauges describing the state of everyone's plants. They
+// are computed from a snapshot refreshed on an interval, so scrapes never
+// query the database and always see one consistent snapshot.
+type Household struct {
+	store metricsStore.MetricsStore
+
+	mu          sync.RWMutex
+	plants      []database.GetPlantCareSnapshotRow
+	refreshedAt time.Time
+
+	plantsPerUser     *prometheus.Desc
+	plantsOverdue     *prometheus.Desc
+	hoursSinceWatered *prometheus.Desc
+	refreshed         *prometheus.Desc
+}
+
+// RegisterHousehold exports household gauges read from store. They are empty
+// until the first Refresh.
+func (m *Metrics) RegisterHousehold(store metricsStore.MetricsStore) *Household {
+	desc := func(name string, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "household", name), help, labels, nil)
+	}
+
+	h := &Household{
+		store:             store,
+		plantsPerUser:     desc("plants", "Plants owned, by user.", "user_id"),
+		plantsOverdue:     desc("plants_overdue", "Plants past their care schedule, by event type.", "type"),
+		hoursSinceWatered: desc("hours_since_watered", "Hours since each plant was last watered. Plants never watered are omitted.", "user_id", "plant_id", "plant"),
+		refreshed:         desc("refreshed_timestamp_seconds", "When the household gauges were last refreshed."),
+	}
+	m.registry.MustRegister(h)
+
+	return h
+}
+
+// Refresh replaces the snapshot the gauges are computed from
+func (h *Household) Refresh(ctx context.Context) error {
+	plants, err := h.store.GetPlantCareSnapshot(ctx, database.GetPlantCareSnapshotParams{
+		WaterIntervalDays:      plantsService.WaterIntervalDays,
+		FertilizerIntervalDays: plantsService.FertilizerIntervalDays,
+	})
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.plants = plants
+	h.refreshedAt = time.Now()
+
+	return nil
+}
+
+// Run refreshes the snapshot straight away and then every interval until ctx
+// is cancelled. Failed refreshes are logged and the previous snapshot kept.
+func (h *Household) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := h.Refresh(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Failed to refresh household metrics", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *Household) Describe(ch chan<- *prometheus.Desc) {
+	ch <- h.plantsPerUser
+	ch <- h.plantsOverdue
+	ch <- h.hoursSinceWatered
+	ch <- h.refreshed
+}
+
+func (h *Household) Collect(ch chan<- prometheus.Metric) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.refreshedAt.IsZero() {
+		return
+	}
+
+	plantsPerUser := make(map[int64]int)
+	waterOverdue, fertilizerOverdue := 0, 0
+	for _, plant := range h.plants {
+		userId := strconv.FormatInt(plant.Userid, 10)
+		plantsPerUser[plant.Userid]++
+
+		if plant.WaterOverdue {
+			waterOverdue++
+		}
+		if plant.FertilizerOverdue {
+			fertilizerOverdue++
+		}
+
+		if plant.Watered {
+			ch <- prometheus.MustNewConstMetric(h.hoursSinceWatered, prometheus.GaugeValue, plant.HoursSinceWatered,
+				userId, strconv.FormatInt(plant.ID, 10), plant.Name)
+		}
+	}
+
+	for userId, count := range plantsPerUser {
+		ch <- prometheus.MustNewConstMetric(h.plantsPerUser, prometheus.GaugeValue, float64(count), strconv.FormatInt(userId, 10))
+	}
+
+	ch <- prometheus.MustNewConstMetric(h.plantsOverdue, prometheus.GaugeValue, float64(waterOverdue), eventTypeLabel(1))
+	ch <- prometheus.MustNewConstMetric(h.plantsOverdue, prometheus.GaugeValue, float64(fertilizerOverdue), eventTypeLabel(2))
+	ch <- prometheus.MustNewConstMetric(h.refreshed, prometheus.GaugeValue, float64(h.refreshedAt.Unix()))
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "plant_tracker"
+
+// Metrics owns the Prometheus registry for the API. A private registry keeps
+// the exposed series to the ones registered here.
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	eventsCreated   *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests served, by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by method and route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		eventsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_created_total",
+			Help:      "Care events created, by event type.",
+		}, []string{"type"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.eventsCreated,
+	)
+
+	return m
+}
+
+// Handler serves the registered metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveRequest records a served request. Routes are the mux pattern rather
+// than the path so that IDs don't each become their own series.
+func (m *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	method = normaliseMethod(method)
+
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// RegisterPool exports the pool's connection statistics, read at scrape time
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(newPoolCollector(pool))
+}
+
+// EventCreated counts created events, so Metrics can be passed to eventsService
+// as a listener. Care merged into an existing event isn't counted.
+func (m *Metrics) EventCreated(ctx context.Context, ownerId int64, actorId int64, event database.Event, merged bool) {
+	if merged {
+		return
+	}
+	m.eventsCreated.WithLabelValues(eventTypeLabel(event.Eventtype)).Inc()
+}
+
+func eventTypeLabel(eventType int32) string {
+	switch eventType {
+	case 1:
+		return "water"
+	case 2:
+		return "fertilize"
+	default:
+		return strconv.Itoa(int(eventType))
+	}
+}
+
+// normaliseMethod stops arbitrary client supplied methods from creating series
+func normaliseMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
@@ -0,0 +1,152 @@
+package rateLimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limit is a token bucket: Rate requests per second on average, with up to
+// Burst at once after a quiet spell
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Decision is the outcome of one request against its client's bucket
+type Decision struct {
+	Allowed bool
+	// Limit is the bucket size and Remaining how many requests are left in it
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be allowed, zero
+	// if it already would be
+	RetryAfter time.Duration
+}
+
+// Limiter keeps a token bucket per client and budget. Reads and writes have
+// separate budgets shared by every route, and routes listed in routes get a
+// budget of their own instead.
+type Limiter struct {
+	read        Limit
+	write       Limit
+	routes      map[string]Limit
+	idleTimeout time.Duration
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+}
+
+type bucketKey struct {
+	budget string
+	client string
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New creates a limiter. Buckets are dropped by Run once their client has
+// been idle for idleTimeout and they have refilled.
+func New(read Limit, write Limit, routes map[string]Limit, idleTimeout time.Duration) *Limiter {
+	return &Limiter{
+		read:        read,
+		write:       write,
+		routes:      routes,
+		idleTimeout: idleTimeout,
+		buckets:     make(map[bucketKey]*bucket),
+	}
+}
+
+// Allow takes a token from the client's bucket for route, a method and path
+// pattern such as "POST /users/{id}/plants"
+func (l *Limiter) Allow(route string, method string, client string) Decision {
+	budget, limit := l.budget(route, method)
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := bucketKey{budget: budget, client: client}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	allowed := b.limiter.AllowN(now, 1)
+	tokens := b.limiter.TokensAt(now)
+
+	decision := Decision{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		decision.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	return decision
+}
+
+func (l *Limiter) budget(route string, method string) (string, Limit) {
+	if limit, ok := l.routes[route]; ok {
+		return route, limit
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return "read", l.read
+	}
+	return "write", l.write
+}
+
+// Routes lists the routes given their own budget
+func (l *Limiter) Routes() []string {
+	routes := make([]string, 0, len(l.routes))
+	for route := range l.routes {
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// Run drops the buckets of idle clients every interval until ctx is cancelled.
+// Only full buckets are dropped, as they are recreated full.
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.removeIdle(time.Now())
+		}
+	}
+}
+
+func (l *Limiter) removeIdle(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, b := range l.buckets {
+		full := b.limiter.TokensAt(now) >= float64(b.limiter.Burst())
+		if full && now.Sub(b.lastSeen) > l.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,98 @@
+package rateLimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowStopsAtBurst(t *testing.T) {
+	limiter := New(Limit{Rate: 1, Burst: 3}, Limit{Rate: 1, Burst: 1}, nil, time.Minute)
+
+	for i := range 3 {
+		decision := limiter.Allow("GET /users/{id}/plants", "GET", "ip:10.0.0.1")
+		if !decision.Allowed {
+			t.Fatalf("request %d was denied within the burst", i+1)
+		}
+		if decision.Limit != 3 {
+			t.Errorf("request %d has limit %d, want 3", i+1, decision.Limit)
+		}
+		if decision.Remaining != 2-i {
+			t.Errorf("request %d has %d remaining, want %d", i+1, decision.Remaining, 2-i)
+		}
+		if decision.RetryAfter != 0 {
+			t.Errorf("request %d was allowed but has RetryAfter %s", i+1, decision.RetryAfter)
+		}
+	}
+
+	decision := limiter.Allow("GET /users/{id}/plants", "GET", "ip:10.0.0.1")
+	if decision.Allowed {
+		t.Fatal("request past the burst was allowed")
+	}
+	if decision.Remaining != 0 {
+		t.Errorf("denied request has %d remaining, want 0", decision.Remaining)
+	}
+	if decision.RetryAfter <= 0 || decision.RetryAfter > time.Second {
+		t.Errorf("denied request has RetryAfter %s, want up to 1s", decision.RetryAfter)
+	}
+	if decision.Reset <= 0 || decision.Reset > 3*time.Second {
+		t.Errorf("denied request has Reset %s, want up to 3s", decision.Reset)
+	}
+}
+
+func TestAllowSeparatesBudgets(t *testing.T) {
+	routes := map[string]Limit{"POST /users/{id}/import": {Rate: 1, Burst: 1}}
+	limiter := New(Limit{Rate: 1, Burst: 1}, Limit{Rate: 1, Burst: 1}, routes, time.Minute)
+	client := "ip:10.0.0.1"
+
+	requests := []struct {
+		route  string
+		method string
+	}{
+		{"GET /users/{id}/plants", "GET"},
+		{"HEAD /users/{id}/plants", "HEAD"},
+		{"POST /users/{id}/plants", "POST"},
+		{"DELETE /users/{userId}/plants/{plantId}", "DELETE"},
+		{"POST /users/{id}/import", "POST"},
+	}
+	want := []bool{true, false, true, false, true}
+
+	for i, request := range requests {
+		decision := limiter.Allow(request.route, request.method, client)
+		if decision.Allowed != want[i] {
+			t.Errorf("%s: allowed is %t, want %t", request.route, decision.Allowed, want[i])
+		}
+	}
+}
+
+func TestAllowSeparatesClients(t *testing.T) {
+	limiter := New(Limit{Rate: 1, Burst: 1}, Limit{Rate: 1, Burst: 1}, nil, time.Minute)
+
+	if !limiter.Allow("GET /users/{id}", "GET", "ip:10.0.0.1").Allowed {
+		t.Fatal("first request from a client was denied")
+	}
+	if limiter.Allow("GET /users/{id}", "GET", "ip:10.0.0.1").Allowed {
+		t.Error("second request from a client with a burst of 1 was allowed")
+	}
+	if !limiter.Allow("GET /users/{id}", "GET", "ip:10.0.0.2").Allowed {
+		t.Error("a client on another IP was denied")
+	}
+}
+
+func TestRemoveIdleKeepsRefillingBuckets(t *testing.T) {
+	limiter := New(Limit{Rate: 1, Burst: 2}, Limit{Rate: 1, Burst: 2}, nil, time.Minute)
+	limiter.Allow("GET /users/{id}", "GET", "ip:10.0.0.1")
+	limiter.Allow("GET /users/{id}", "GET", "ip:10.0.0.2")
+	limiter.Allow("GET /users/{id}", "GET", "ip:10.0.0.2")
+
+	// Long enough for both to have refilled and gone idle
+	limiter.removeIdle(time.Now().Add(2 * time.Minute))
+	if len(limiter.buckets) != 0 {
+		t.Errorf("%d buckets left after every client went idle, want 0", len(limiter.buckets))
+	}
+
+	limiter.Allow("GET /users/{id}", "GET", "ip:10.0.0.1")
+	limiter.removeIdle(time.Now())
+	if len(limiter.buckets) != 1 {
+		t.Errorf("%d buckets left after removing none idle, want 1", len(limiter.buckets))
+	}
+}
@@ -0,0 +1,261 @@
+package achievementsService
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/logging"
+	"github.com/ReidMason/plant-tracker/src/services/plantsService"
+	achievementsStore "github.com/ReidMason/plant-tracker/src/stores/achievementsStore"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	usersStore "github.com/ReidMason/plant-tracker/src/stores/usersStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+)
+
+type AchievementsService interface {
+	GetAchievements(ctx context.Context, userId int64) (Summary, error)
+	EvaluateAchievements(ctx context.Context, userId int64) ([]Achievement, error)
+	EventCreated(ctx context.Context, ownerId int64, actorId int64, event database.Event, merged bool)
+}
+
+// Progress is everything the achievement rules are evaluated against
+type Progress struct {
+	WaterCount          int64
+	FertilizeCount      int64
+	PlantsCaredFor      int64
+	PlantsWatered       int64
+	WaterStreakDays     int
+	FertilizeStreakDays int
+}
+
+type rule struct {
+	code        string
+	name        string
+	description string
+	earned      func(progress Progress) bool
+}
+
+var rules = []rule{
+	{
+		code:        "first-watering",
+		name:        "First Sip",
+		description: "Water a plant for the first time",
+		earned:      func(p Progress) bool { return p.WaterCount >= 1 },
+	},
+	{
+		code:        "first-fertilizing",
+		name:        "Plant Food",
+		description: "Fertilize a plant for the first time",
+		earned:      func(p Progress) bool { return p.FertilizeCount >= 1 },
+	},
+	{
+		code:        "watered-50",
+		name:        "Watering Can",
+		description: "Water 50 plants",
+		earned:      func(p Progress) bool { return p.PlantsWatered >= 50 },
+	},
+	{
+		code:        "watered-250",
+		name:        "Rain Maker",
+		description: "Water 250 plants",
+		earned:      func(p Progress) bool { return p.PlantsWatered >= 250 },
+	},
+	{
+		code:        "plants-10",
+		name:        "Green Thumb",
+		description: "Care for 10 different plants",
+		earned:      func(p Progress) bool { return p.PlantsCaredFor >= 10 },
+	},
+	{
+		code:        "water-streak-30",
+		name:        "Creature of Habit",
+		description: "Keep every plant watered on time for 30 days",
+		earned:      func(p Progress) bool { return p.WaterStreakDays >= 30 },
+	},
+	{
+		code:        "water-streak-365",
+		name:        "Evergreen",
+		description: "Keep every plant watered on time for a whole year",
+		earned:      func(p Progress) bool { return p.WaterStreakDays >= 365 },
+	},
+}
+
+type Achievement struct {
+	EarnedAt    time.Time
+	Code        string
+	Name        string
+	Description string
+}
+
+// Summary holds a user's current streaks and every achievement they could earn
+type Summary struct {
+	Achievements        []Achievement
+	WaterStreakDays     int
+	FertilizeStreakDays int
+}
+
+type achievementsService struct {
+	achievementsStore achievementsStore.AchievementsStore
+	usersStore        usersStore.UsersStore
+}
+
+func New(achievementsStore achievementsStore.AchievementsStore, usersStore usersStore.UsersStore) *achievementsService {
+	return &achievementsService{
+		achievementsStore: achievementsStore,
+		usersStore:        usersStore,
+	}
+}
+
+func (s *achievementsService) GetAchievements(ctx context.Context, userId int64) (Summary, error) {
+	ctx, span := tracing.Start(ctx, "achievementsService.GetAchievements")
+	defer span.End()
+
+	if _, err := s.usersStore.GetUserById(ctx, userId); err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return Summary{}, ErrUserNotFound
+		}
+		return Summary{}, err
+	}
+
+	earned, err := s.achievementsStore.GetAchievementsByUserId(ctx, userId)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	earnedAt := make(map[string]time.Time, len(earned))
+	for _, achievement := range earned {
+		earnedAt[achievement.Code] = achievement.Earnedat
+	}
+
+	now := time.Now()
+	waterStreak, err := s.getStreakDays(ctx, userId, 1, plantsService.WaterIntervalDays, now)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	fertilizeStreak, err := s.getStreakDays(ctx, userId, 2, plantsService.FertilizerIntervalDays, now)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	summary := Summary{
+		Achievements:        make([]Achievement, len(rules)),
+		WaterStreakDays:     waterStreak,
+		FertilizeStreakDays: fertilizeStreak,
+	}
+	for i, rule := range rules {
+		summary.Achievements[i] = rule.toAchievement(earnedAt[rule.code])
+	}
+
+	return summary, nil
+}
+
+// EvaluateAchievements awards any achievements the user has newly qualified for
+func (s *achievementsService) EvaluateAchievements(ctx context.Context, userId int64) ([]Achievement, error) {
+	ctx, span := tracing.Start(ctx, "achievementsService.EvaluateAchievements")
+	defer span.End()
+
+	now := time.Now()
+	progress, err := s.getProgress(ctx, userId, now)
+	if err != nil {
+		return nil, err
+	}
+
+	newlyEarned := make([]Achievement, 0)
+	for _, rule := range rules {
+		if !rule.earned(progress) {
+			continue
+		}
+
+		created, err := s.achievementsStore.CreateAchievement(ctx, database.CreateAchievementParams{
+			Userid:   userId,
+			Code:     rule.code,
+			Earnedat: now,
+		})
+		if err != nil {
+			return newlyEarned, err
+		}
+
+		if created > 0 {
+			newlyEarned = append(newlyEarned, rule.toAchievement(now))
+		}
+	}
+
+	return newlyEarned, nil
+}
+
+// EventCreated re-evaluates achievements whenever a plant receives care. Care
+// counts towards whoever did it, including when merged into a recent event as
+// that credits them for it, and streaks towards the plant's owner, which a
+// merge doesn't change.
+func (s *achievementsService) EventCreated(ctx context.Context, ownerId int64, actorId int64, event database.Event, merged bool) {
+	userIds := []int64{actorId}
+	if !merged && ownerId != actorId {
+		userIds = append(userIds, ownerId)
+	}
+
+	for _, userId := range userIds {
+		if _, err := s.EvaluateAchievements(ctx, userId); err != nil {
+			logging.FromContext(ctx).Error("Failed to evaluate achievements", "userId", userId, "error", err)
+		}
+	}
+}
+
+func (s *achievementsService) getProgress(ctx context.Context, userId int64, now time.Time) (Progress, error) {
+	counts, err := s.achievementsStore.GetCareProgressByUserId(ctx, userId)
+	if err != nil {
+		return Progress{}, err
+	}
+
+	waterStreak, err := s.getStreakDays(ctx, userId, 1, plantsService.WaterIntervalDays, now)
+	if err != nil {
+		return Progress{}, err
+	}
+
+	fertilizeStreak, err := s.getStreakDays(ctx, userId, 2, plantsService.FertilizerIntervalDays, now)
+	if err != nil {
+		return Progress{}, err
+	}
+
+	return Progress{
+		WaterCount:          counts.WaterCount,
+		FertilizeCount:      counts.FertilizeCount,
+		PlantsCaredFor:      counts.PlantsCaredFor,
+		PlantsWatered:       counts.PlantsWatered,
+		WaterStreakDays:     waterStreak,
+		FertilizeStreakDays: fertilizeStreak,
+	}, nil
+}
+
+// getStreakDays counts the days since an event of the given type was last late,
+// dropping to zero while any plant is overdue
+func (s *achievementsService) getStreakDays(ctx context.Context, userId int64, eventType int32, intervalDays int32, now time.Time) (int, error) {
+	streak, err := s.achievementsStore.GetCareStreakByUserId(ctx, database.GetCareStreakByUserIdParams{
+		UserID:       userId,
+		EventType:    eventType,
+		IntervalDays: intervalDays,
+		Now:          now,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if streak.Overdue {
+		return 0, nil
+	}
+
+	return int(now.Sub(streak.StreakStart).Hours() / 24), nil
+}
+
+func (r rule) toAchievement(earnedAt time.Time) Achievement {
+	return Achievement{
+		Code:        r.code,
+		Name:        r.name,
+		Description: r.description,
+		EarnedAt:    earnedAt,
+	}
+}
+
+var ErrUserNotFound = domainErrors.New(domainErrors.NotFound, "User not found")
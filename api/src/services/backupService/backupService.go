@@ -0,0 +1,398 @@
+package backupService
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	exportStore "github.com/ReidMason/plant-tracker/src/stores/exportStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+// FormatVersion is bumped whenever the shape of the archived records changes
+const FormatVersion = 2
+
+const ManifestFileName = "manifest.json"
+
+// Archive entries in the order they must be restored to satisfy foreign keys
+const (
+	eventTypesFileName   = "eventTypes.jsonl"
+	usersFileName        = "users.jsonl"
+	plantsFileName       = "plants.jsonl"
+	eventsFileName       = "events.jsonl"
+	achievementsFileName = "achievements.jsonl"
+)
+
+// restoreBatchSize is the number of rows sent per COPY while restoring
+const restoreBatchSize = 1000
+
+// Manifest describes a backup archive and lets restores verify its contents
+type Manifest struct {
+	CreatedAt     time.Time      `json:"createdAt"`
+	Files         []ManifestFile `json:"files"`
+	FormatVersion int            `json:"formatVersion"`
+	SchemaVersion int64          `json:"schemaVersion"`
+}
+
+type ManifestFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Rows   int64  `json:"rows"`
+}
+
+func (m Manifest) file(name string) (ManifestFile, error) {
+	for _, file := range m.Files {
+		if file.Name == name {
+			return file, nil
+		}
+	}
+	return ManifestFile{}, fmt.Errorf("manifest is missing %s", name)
+}
+
+type userRecord struct {
+	Name   string `json:"name"`
+	Colour string `json:"colour"`
+	Id     int64  `json:"id"`
+}
+
+type eventTypeRecord struct {
+	Name string `json:"name"`
+	Id   int32  `json:"id"`
+}
+
+type plantRecord struct {
+	Name   string `json:"name"`
+	Id     int64  `json:"id"`
+	UserId int64  `json:"userId"`
+}
+
+type eventRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Note      string    `json:"note"`
+	Id        int64     `json:"id"`
+	PlantId   int64     `json:"plantId"`
+	TypeId    int32     `json:"typeId"`
+	// ActorIds was added in format version 2
+	ActorIds []int64 `json:"actorIds"`
+}
+
+type achievementRecord struct {
+	EarnedAt time.Time `json:"earnedAt"`
+	Code     string    `json:"code"`
+	UserId   int64     `json:"userId"`
+}
+
+// TxStarter begins the transactions backups are read and restored in
+type TxStarter interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+type BackupService struct {
+	db      TxStarter
+	queries *database.Queries
+}
+
+func New(db TxStarter, queries *database.Queries) *BackupService {
+	return &BackupService{
+		db:      db,
+		queries: queries,
+	}
+}
+
+// Backup writes every table to a zip archive from a single consistent snapshot
+func (s *BackupService) Backup(ctx context.Context, w io.Writer, schemaVersion int64) (Manifest, error) {
+	ctx, span := tracing.Start(ctx, "backupService.Backup")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer tx.Rollback(ctx)
+	q := exportStore.New(tx)
+
+	manifest := Manifest{
+		CreatedAt:     time.Now().UTC(),
+		FormatVersion: FormatVersion,
+		SchemaVersion: schemaVersion,
+	}
+
+	archive := zip.NewWriter(w)
+	writers := []func() (ManifestFile, error){
+		func() (ManifestFile, error) {
+			return writeTable(ctx, archive, eventTypesFileName, q.StreamEventTypes, func(t database.Eventtype) eventTypeRecord {
+				return eventTypeRecord{Id: t.ID, Name: t.Name}
+			})
+		},
+		func() (ManifestFile, error) {
+			return writeTable(ctx, archive, usersFileName, q.StreamUsers, func(u database.User) userRecord {
+				return userRecord{Id: u.ID, Name: u.Name, Colour: u.Colour}
+			})
+		},
+		func() (ManifestFile, error) {
+			return writeTable(ctx, archive, plantsFileName, q.StreamPlants, func(p database.Plant) plantRecord {
+				return plantRecord{Id: p.ID, Name: p.Name, UserId: p.Userid}
+			})
+		},
+		func() (ManifestFile, error) {
+			return writeTable(ctx, archive, eventsFileName, q.StreamEvents, func(e database.Event) eventRecord {
+				return eventRecord{Id: e.ID, PlantId: e.Plantid, TypeId: e.Eventtype, Note: e.Note, Timestamp: e.Timestamp, ActorIds: e.Actorids}
+			})
+		},
+		func() (ManifestFile, error) {
+			return writeTable(ctx, archive, achievementsFileName, q.StreamAchievements, func(a database.Achievement) achievementRecord {
+				return achievementRecord{UserId: a.Userid, Code: a.Code, EarnedAt: a.Earnedat}
+			})
+		},
+	}
+
+	for _, write := range writers {
+		file, err := write()
+		if err != nil {
+			return Manifest{}, err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	manifestFile, err := archive.Create(ManifestFileName)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	encoder := json.NewEncoder(manifestFile)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return Manifest{}, err
+	}
+
+	return manifest, archive.Close()
+}
+
+func writeTable[T any, R any](ctx context.Context, archive *zip.Writer, name string, stream func(context.Context, func(T) error) error, toRecord func(T) R) (ManifestFile, error) {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return ManifestFile{}, err
+	}
+
+	hash := sha256.New()
+	encoder := json.NewEncoder(io.MultiWriter(entry, hash))
+	file := ManifestFile{Name: name}
+	err = stream(ctx, func(row T) error {
+		file.Rows++
+		return encoder.Encode(toRecord(row))
+	})
+	if err != nil {
+		return ManifestFile{}, fmt.Errorf("failed to back up %s: %w", name, err)
+	}
+
+	file.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
+
+// Restore loads an archive into a migrated but otherwise empty database. The
+// whole restore happens in one transaction and is rolled back if any file
+// fails its checksum.
+func (s *BackupService) Restore(ctx context.Context, r io.ReaderAt, size int64, schemaVersion int64) (Manifest, error) {
+	ctx, span := tracing.Start(ctx, "backupService.Restore")
+	defer span.End()
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	manifest, err := readManifest(archive)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if manifest.FormatVersion > FormatVersion {
+		return Manifest{}, fmt.Errorf("archive format version %d is newer than supported version %d", manifest.FormatVersion, FormatVersion)
+	}
+
+	if manifest.SchemaVersion > schemaVersion {
+		return Manifest{}, fmt.Errorf("archive schema version %d is newer than database schema version %d", manifest.SchemaVersion, schemaVersion)
+	}
+
+	tx, err := s.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer tx.Rollback(ctx)
+	q := s.queries.WithTx(tx)
+
+	counts, err := q.CountUserData(ctx)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if counts.Users > 0 || counts.Plants > 0 || counts.Events > 0 {
+		return Manifest{}, ErrDatabaseNotEmpty
+	}
+
+	err = readTable(archive, manifest, eventTypesFileName, func(batch []eventTypeRecord) error {
+		for _, t := range batch {
+			if err := q.RestoreEventType(ctx, database.RestoreEventTypeParams{ID: t.Id, Name: t.Name}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	err = readTable(archive, manifest, usersFileName, func(batch []userRecord) error {
+		params := make([]database.RestoreUsersParams, len(batch))
+		for i, u := range batch {
+			params[i] = database.RestoreUsersParams{ID: u.Id, Name: u.Name, Colour: u.Colour}
+		}
+		_, err := q.RestoreUsers(ctx, params)
+		return err
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	err = readTable(archive, manifest, plantsFileName, func(batch []plantRecord) error {
+		params := make([]database.RestorePlantsParams, len(batch))
+		for i, p := range batch {
+			params[i] = database.RestorePlantsParams{ID: p.Id, Name: p.Name, Userid: p.UserId}
+		}
+		_, err := q.RestorePlants(ctx, params)
+		return err
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	err = readTable(archive, manifest, eventsFileName, func(batch []eventRecord) error {
+		params := make([]database.RestoreEventsParams, len(batch))
+		for i, e := range batch {
+			actorIds := e.ActorIds
+			if actorIds == nil {
+				actorIds = []int64{}
+			}
+			params[i] = database.RestoreEventsParams{ID: e.Id, Plantid: e.PlantId, Eventtype: e.TypeId, Note: e.Note, Timestamp: e.Timestamp, Actorids: actorIds}
+		}
+		_, err := q.RestoreEvents(ctx, params)
+		return err
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	err = readTable(archive, manifest, achievementsFileName, func(batch []achievementRecord) error {
+		params := make([]database.RestoreAchievementsParams, len(batch))
+		for i, a := range batch {
+			params[i] = database.RestoreAchievementsParams{Userid: a.UserId, Code: a.Code, Earnedat: a.EarnedAt}
+		}
+		_, err := q.RestoreAchievements(ctx, params)
+		return err
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if manifest.FormatVersion < 2 {
+		if err := q.BackfillEventActors(ctx); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	// Rows were inserted with their original ids so move the sequences past them
+	for _, reset := range []func(context.Context) error{q.ResetUsersSequence, q.ResetPlantsSequence, q.ResetEventsSequence} {
+		if err := reset(ctx); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	return manifest, tx.Commit(ctx)
+}
+
+func readManifest(archive *zip.Reader) (Manifest, error) {
+	file, err := archive.Open(ManifestFileName)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("archive has no %s: %w", ManifestFileName, err)
+	}
+	defer file.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(file).Decode(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("failed to parse %s: %w", ManifestFileName, err)
+	}
+
+	return manifest, nil
+}
+
+// readTable decodes an archive entry in batches, verifying its row count and
+// checksum against the manifest once the whole entry has been read
+func readTable[R any](archive *zip.Reader, manifest Manifest, name string, restore func([]R) error) error {
+	expected, err := manifest.file(name)
+	if err != nil {
+		return err
+	}
+
+	entry, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer entry.Close()
+
+	hash := sha256.New()
+	decoder := json.NewDecoder(io.TeeReader(entry, hash))
+	batch := make([]R, 0, restoreBatchSize)
+	var rows int64
+	for {
+		var record R
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		rows++
+		batch = append(batch, record)
+		if len(batch) == restoreBatchSize {
+			if err := restore(batch); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", name, err)
+			}
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := restore(batch); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+	}
+
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != expected.SHA256 {
+		return fmt.Errorf("%s failed checksum verification", name)
+	}
+
+	if rows != expected.Rows {
+		return fmt.Errorf("%s contains %d rows but the manifest lists %d", name, rows, expected.Rows)
+	}
+
+	return nil
+}
+
+type backupError string
+
+func (e backupError) Error() string {
+	return string(e)
+}
+
+const (
+	ErrDatabaseNotEmpty backupError = "database already contains data, restores require an empty database"
+)
@@ -2,77 +2,272 @@ package eventsService
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"time"
 
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/logging"
 	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/ReidMason/plant-tracker/src/stores/dbErrors"
 	eventsStore "github.com/ReidMason/plant-tracker/src/stores/eventsStore"
 	plantsStore "github.com/ReidMason/plant-tracker/src/stores/plantsStore"
+	usersStore "github.com/ReidMason/plant-tracker/src/stores/usersStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+	"github.com/jackc/pgx/v5"
 )
 
+// TxStarter begins the transaction an event is checked for duplicates and
+// recorded in
+type TxStarter interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 type EventsService interface {
-	GetEventsByPlantId(ctx context.Context, plantId int64) ([]database.Event, error)
-	CreateEvent(ctx context.Context, plantId int64, eventType int32, note string) (database.Event, error)
-	CreateWateringEvent(ctx context.Context, plantId int64, note string) (database.Event, error)
-	CreateFertilizeEvent(ctx context.Context, plantId int64, note string) (database.Event, error)
+	GetEventsByPlantId(ctx context.Context, userId int64, plantId int64) ([]database.Event, error)
+	GetEventsVersion(ctx context.Context, userId int64, plantId int64) (time.Time, error)
+	CreateEvent(ctx context.Context, userId int64, plantId int64, newEvent NewEvent) (event database.Event, merged bool, err error)
+	CreateWateringEvent(ctx context.Context, userId int64, plantId int64, note string) (database.Event, error)
+	CreateFertilizeEvent(ctx context.Context, userId int64, plantId int64, note string) (database.Event, error)
 	GetEventById(ctx context.Context, id int64) (database.Event, error)
 	GetLatestEventsByTypeForPlant(ctx context.Context, plantid int64) ([]database.Event, error)
 	GetLatestWaterAndFertilizerEvents(ctx context.Context, plantid int64) (waterEvent, fertilizerEvent database.Event, err error)
 }
 
+// EventListener is notified after care has been recorded for one of a user's
+// plants by actorId, which is the owner unless a housemate did it. merged is
+// true if the care was merged into a recent event rather than creating one.
+type EventListener interface {
+	EventCreated(ctx context.Context, ownerId int64, actorId int64, event database.Event, merged bool)
+}
+
+// NewEvent is care to record for a plant
+type NewEvent struct {
+	Type int32
+	Note string
+	// ActorId is the user who did the care, the plant's owner if zero
+	ActorId int64
+	// Force records the event even if it duplicates a recent one
+	Force bool
+}
+
+// DedupeAction is what is done with an event that duplicates a recent one
+type DedupeAction string
+
+const (
+	// DedupeMerge adds the duplicate's note and actor to the recent event
+	DedupeMerge DedupeAction = "merge"
+	// DedupeReject refuses the duplicate with a DuplicateError
+	DedupeReject DedupeAction = "reject"
+)
+
+// Dedupe treats a new event as a duplicate if one of the same type was
+// recorded for the plant within Window. A zero Window turns it off.
+type Dedupe struct {
+	Window time.Duration
+	Action DedupeAction
+}
+
+// DuplicateError is returned for an event rejected as a duplicate of Event
+type DuplicateError struct {
+	Event database.Event
+}
+
+func (e *DuplicateError) Error() string {
+	return ErrDuplicateEvent.Message
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicateEvent
+}
+
 type eventsService struct {
+	db          TxStarter
 	eventsStore eventsStore.EventsStore
 	plantsStore plantsStore.PlantsStore
+	usersStore  usersStore.UsersStore
+	dedupe      map[int32]Dedupe
+	listeners   []EventListener
 }
 
-func New(eventsStore eventsStore.EventsStore, plantsStore plantsStore.PlantsStore) *eventsService {
+// New creates the service. dedupe is keyed by event type, types without an
+// entry are never treated as duplicates.
+func New(db TxStarter, eventsStore eventsStore.EventsStore, plantsStore plantsStore.PlantsStore, usersStore usersStore.UsersStore, dedupe map[int32]Dedupe, listeners ...EventListener) *eventsService {
 	return &eventsService{
+		db:          db,
 		eventsStore: eventsStore,
 		plantsStore: plantsStore,
+		usersStore:  usersStore,
+		dedupe:      dedupe,
+		listeners:   listeners,
+	}
+}
+
+// getUserPlant loads a plant, treating one owned by another user as missing
+func (s *eventsService) getUserPlant(ctx context.Context, userId int64, plantId int64) (database.Plant, error) {
+	plant, err := s.plantsStore.GetPlantById(ctx, plantId)
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return database.Plant{}, ErrPlantNotFound
+		}
+		return database.Plant{}, err
+	}
+
+	if plant.Userid != userId {
+		return database.Plant{}, ErrPlantNotFound
 	}
+
+	return plant, nil
 }
 
-func (s *eventsService) GetEventsByPlantId(ctx context.Context, plantId int64) ([]database.Event, error) {
+func (s *eventsService) GetEventsByPlantId(ctx context.Context, userId int64, plantId int64) ([]database.Event, error) {
+	ctx, span := tracing.Start(ctx, "eventsService.GetEventsByPlantId")
+	defer span.End()
+
+	if _, err := s.getUserPlant(ctx, userId, plantId); err != nil {
+		return nil, err
+	}
+
 	return s.eventsStore.GetEventsByPlantId(ctx, plantId)
 }
 
-func (s *eventsService) CreateEvent(ctx context.Context, plantId int64, eventType int32, note string) (database.Event, error) {
-	plant, err := s.plantsStore.GetPlantById(ctx, plantId)
+// GetEventsVersion returns when the plant's events last changed
+func (s *eventsService) GetEventsVersion(ctx context.Context, userId int64, plantId int64) (time.Time, error) {
+	ctx, span := tracing.Start(ctx, "eventsService.GetEventsVersion")
+	defer span.End()
+
+	// Changing an event touches its plant
+	plant, err := s.getUserPlant(ctx, userId, plantId)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return plant.Updatedat, nil
+}
+
+// CreateEvent records care for a plant. Unless newEvent is forced, an event
+// of the same type recorded within the type's dedupe window is either merged
+// into, in which case merged is true and the merged event is returned, or
+// causes a DuplicateError.
+func (s *eventsService) CreateEvent(ctx context.Context, userId int64, plantId int64, newEvent NewEvent) (event database.Event, merged bool, err error) {
+	ctx, span := tracing.Start(ctx, "eventsService.CreateEvent")
+	defer span.End()
+
+	plant, err := s.getUserPlant(ctx, userId, plantId)
 	if err != nil {
-		return database.Event{}, err
+		return database.Event{}, false, err
 	}
 
-	if plant == (database.Plant{}) {
-		return database.Event{}, plantsErrorNotFound
+	actorId := newEvent.ActorId
+	if actorId == 0 {
+		actorId = plant.Userid
+	} else if actorId != plant.Userid {
+		if _, err := s.usersStore.GetUserById(ctx, actorId); err != nil {
+			if errors.Is(err, domainErrors.NotFound) {
+				return database.Event{}, false, ErrActorNotFound
+			}
+			return database.Event{}, false, err
+		}
 	}
 
-	return s.eventsStore.CreateEvent(ctx, database.CreateEventParams{
+	params := database.CreateEventParams{
 		Plantid:   plantId,
-		Eventtype: eventType,
-		Note:      note,
+		Eventtype: newEvent.Type,
+		Note:      newEvent.Note,
 		Timestamp: time.Now(),
+		Actorids:  []int64{actorId},
+	}
+
+	if dedupe, ok := s.dedupe[newEvent.Type]; ok && dedupe.Window > 0 && !newEvent.Force {
+		event, merged, err = s.createDeduped(ctx, dedupe, params, actorId)
+	} else {
+		event, err = s.eventsStore.CreateEvent(ctx, params)
+	}
+	if err != nil {
+		return database.Event{}, false, err
+	}
+
+	for _, listener := range s.listeners {
+		listener.EventCreated(ctx, plant.Userid, actorId, event, merged)
+	}
+
+	return event, merged, nil
+}
+
+// createDeduped records an event unless one of the same type was recorded
+// within the dedupe window. The plant is locked while checking so duplicates
+// sent at the same time can't both be recorded.
+func (s *eventsService) createDeduped(ctx context.Context, dedupe Dedupe, params database.CreateEventParams, actorId int64) (event database.Event, merged bool, err error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return database.Event{}, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	q := database.New(dbErrors.Wrap(tx))
+	if err := q.LockPlant(ctx, params.Plantid); err != nil {
+		return database.Event{}, false, err
+	}
+
+	recent, err := q.GetRecentEvent(ctx, database.GetRecentEventParams{
+		Plantid:   params.Plantid,
+		Eventtype: params.Eventtype,
+		Since:     params.Timestamp.Add(-dedupe.Window),
 	})
+	switch {
+	case err == nil && dedupe.Action == DedupeReject:
+		return database.Event{}, false, &DuplicateError{Event: recent}
+	case err == nil:
+		event, err = q.MergeIntoEvent(ctx, database.MergeIntoEventParams{
+			ID:      recent.ID,
+			Note:    params.Note,
+			ActorID: actorId,
+		})
+		merged = true
+	case errors.Is(err, domainErrors.NotFound):
+		event, err = q.CreateEvent(ctx, params)
+	}
+	if err != nil {
+		return database.Event{}, false, err
+	}
+
+	return event, merged, tx.Commit(ctx)
 }
 
-func (s *eventsService) CreateWateringEvent(ctx context.Context, plantId int64, note string) (database.Event, error) {
-	return s.CreateEvent(ctx, plantId, 1, note)
+func (s *eventsService) CreateWateringEvent(ctx context.Context, userId int64, plantId int64, note string) (database.Event, error) {
+	ctx, span := tracing.Start(ctx, "eventsService.CreateWateringEvent")
+	defer span.End()
+
+	event, _, err := s.CreateEvent(ctx, userId, plantId, NewEvent{Type: 1, Note: note})
+	return event, err
 }
 
-func (s *eventsService) CreateFertilizeEvent(ctx context.Context, plantId int64, note string) (database.Event, error) {
-	fmt.Println("Creating fertilize event")
-	return s.CreateEvent(ctx, plantId, 2, note)
+func (s *eventsService) CreateFertilizeEvent(ctx context.Context, userId int64, plantId int64, note string) (database.Event, error) {
+	ctx, span := tracing.Start(ctx, "eventsService.CreateFertilizeEvent")
+	defer span.End()
+
+	logging.FromContext(ctx).Debug("Creating fertilize event", "plantId", plantId)
+	event, _, err := s.CreateEvent(ctx, userId, plantId, NewEvent{Type: 2, Note: note})
+	return event, err
 }
 
 func (s *eventsService) GetEventById(ctx context.Context, id int64) (database.Event, error) {
+	ctx, span := tracing.Start(ctx, "eventsService.GetEventById")
+	defer span.End()
+
 	return s.eventsStore.GetEventById(ctx, id)
 }
 
 func (s *eventsService) GetLatestEventsByTypeForPlant(ctx context.Context, plantId int64) ([]database.Event, error) {
+	ctx, span := tracing.Start(ctx, "eventsService.GetLatestEventsByTypeForPlant")
+	defer span.End()
+
 	return s.eventsStore.GetLatestEventsByTypeForPlant(ctx, plantId)
 }
 
 // GetLatestWaterAndFertilizerEvents efficiently gets both water and fertilizer events in a single query
 func (s *eventsService) GetLatestWaterAndFertilizerEvents(ctx context.Context, plantId int64) (waterEvent, fertilizerEvent database.Event, err error) {
+	ctx, span := tracing.Start(ctx, "eventsService.GetLatestWaterAndFertilizerEvents")
+	defer span.End()
+
 	events, err := s.eventsStore.GetLatestEventsByTypeForPlant(ctx, plantId)
 	if err != nil {
 		return database.Event{}, database.Event{}, err
@@ -91,12 +286,11 @@ func (s *eventsService) GetLatestWaterAndFertilizerEvents(ctx context.Context, p
 	return waterEvent, fertilizerEvent, nil
 }
 
-type plantsError string
-
-func (e plantsError) Error() string {
-	return string(e)
-}
-
-const (
-	plantsErrorNotFound plantsError = "plant not found"
+var (
+	// ErrPlantNotFound is returned for plants that don't exist or belong to another user
+	ErrPlantNotFound = domainErrors.New(domainErrors.NotFound, "Plant not found")
+	// ErrActorNotFound is returned for events said to be made by a user that doesn't exist
+	ErrActorNotFound = &domainErrors.Error{Kind: domainErrors.Validation, Message: "actorId must be an existing user", Field: "actorId"}
+	// ErrDuplicateEvent is wrapped by DuplicateError
+	ErrDuplicateEvent = domainErrors.New(domainErrors.Conflict, "The same care was already recorded for this plant recently, send force to record it again")
 )
@@ -0,0 +1,356 @@
+package eventsService
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	eventsStore "github.com/ReidMason/plant-tracker/src/stores/eventsStore"
+	plantsStore "github.com/ReidMason/plant-tracker/src/stores/plantsStore"
+	usersStore "github.com/ReidMason/plant-tracker/src/stores/usersStore"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	ownerId       = 1
+	housemateId   = 2
+	plantId       = 10
+	waterType     = int32(1)
+	fertilizeType = int32(2)
+)
+
+// fakeDB keeps one plant's events in memory. It serves the stores the
+// service uses outside a transaction, and through fakeTx the generated
+// queries it runs inside one.
+type fakeDB struct {
+	eventsStore.EventsStore
+	plantsStore.PlantsStore
+	usersStore.UsersStore
+
+	events     []database.Event
+	locked     bool
+	committed  bool
+	rolledBack bool
+}
+
+func (f *fakeDB) GetPlantById(ctx context.Context, id int64) (database.Plant, error) {
+	if id != plantId {
+		return database.Plant{}, domainErrors.New(domainErrors.NotFound, "Plant not found")
+	}
+	return database.Plant{ID: plantId, Name: "Fern", Userid: ownerId}, nil
+}
+
+func (f *fakeDB) GetUserById(ctx context.Context, id int64) (database.User, error) {
+	if id != ownerId && id != housemateId {
+		return database.User{}, domainErrors.New(domainErrors.NotFound, "User not found")
+	}
+	return database.User{ID: id}, nil
+}
+
+func (f *fakeDB) CreateEvent(ctx context.Context, arg database.CreateEventParams) (database.Event, error) {
+	event := database.Event{
+		ID:        int64(len(f.events) + 1),
+		Plantid:   arg.Plantid,
+		Eventtype: arg.Eventtype,
+		Note:      arg.Note,
+		Timestamp: arg.Timestamp,
+		Actorids:  arg.Actorids,
+	}
+	f.events = append(f.events, event)
+	return event, nil
+}
+
+func (f *fakeDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{db: f}, nil
+}
+
+// fakeTx answers the generated queries createDeduped runs, picked by the
+// name sqlc puts at the start of each one
+type fakeTx struct {
+	pgx.Tx
+	db *fakeDB
+}
+
+func (t *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if !strings.Contains(sql, "-- name: LockPlant ") {
+		return pgconn.CommandTag{}, errors.New("unexpected exec: " + sql)
+	}
+	t.db.locked = true
+	return pgconn.NewCommandTag("SELECT 1"), nil
+}
+
+func (t *fakeTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if !t.db.locked {
+		return fakeRow{err: errors.New("plant isn't locked")}
+	}
+
+	switch {
+	case strings.Contains(sql, "-- name: GetRecentEvent "):
+		plant, eventType, since := args[0].(int64), args[1].(int32), args[2].(time.Time)
+		for i := len(t.db.events) - 1; i >= 0; i-- {
+			event := t.db.events[i]
+			if event.Plantid == plant && event.Eventtype == eventType && !event.Timestamp.Before(since) {
+				return fakeRow{event: event}
+			}
+		}
+		return fakeRow{err: pgx.ErrNoRows}
+	case strings.Contains(sql, "-- name: MergeIntoEvent "):
+		note, actorId, id := args[0].(string), args[1].(int64), args[2].(int64)
+		event := &t.db.events[id-1]
+		if note != "" && event.Note != "" {
+			event.Note += "\n" + note
+		} else if note != "" {
+			event.Note = note
+		}
+		if !slices.Contains(event.Actorids, actorId) {
+			event.Actorids = append(event.Actorids, actorId)
+		}
+		return fakeRow{event: *event}
+	case strings.Contains(sql, "-- name: CreateEvent "):
+		event, err := t.db.CreateEvent(ctx, database.CreateEventParams{
+			Plantid:   args[0].(int64),
+			Eventtype: args[1].(int32),
+			Note:      args[2].(string),
+			Timestamp: args[3].(time.Time),
+			Actorids:  args[4].([]int64),
+		})
+		return fakeRow{event: event, err: err}
+	}
+	return fakeRow{err: errors.New("unexpected query: " + sql)}
+}
+
+func (t *fakeTx) Commit(ctx context.Context) error {
+	t.db.committed = true
+	return nil
+}
+
+func (t *fakeTx) Rollback(ctx context.Context) error {
+	if !t.db.committed {
+		t.db.rolledBack = true
+	}
+	return nil
+}
+
+type fakeRow struct {
+	event database.Event
+	err   error
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	*dest[0].(*int64) = r.event.ID
+	*dest[1].(*int64) = r.event.Plantid
+	*dest[2].(*int32) = r.event.Eventtype
+	*dest[3].(*string) = r.event.Note
+	*dest[4].(*time.Time) = r.event.Timestamp
+	*dest[5].(*[]int64) = r.event.Actorids
+	return nil
+}
+
+type fakeListener struct {
+	actorIds []int64
+	merged   []bool
+}
+
+func (l *fakeListener) EventCreated(ctx context.Context, ownerId int64, actorId int64, event database.Event, merged bool) {
+	l.actorIds = append(l.actorIds, actorId)
+	l.merged = append(l.merged, merged)
+}
+
+func TestCreateEventDedupes(t *testing.T) {
+	window := 10 * time.Minute
+
+	tests := []struct {
+		name       string
+		action     DedupeAction
+		recentAgo  time.Duration
+		recentType int32
+		newEvent   NewEvent
+		wantMerged bool
+		wantReject bool
+		wantEvents int
+		wantNote   string
+		wantActors []int64
+	}{
+		{
+			name:       "no recent event",
+			action:     DedupeMerge,
+			newEvent:   NewEvent{Type: waterType, Note: "Soaked"},
+			wantEvents: 1,
+			wantNote:   "Soaked",
+			wantActors: []int64{ownerId},
+		},
+		{
+			name:       "merged into recent event",
+			action:     DedupeMerge,
+			recentAgo:  5 * time.Minute,
+			recentType: waterType,
+			newEvent:   NewEvent{Type: waterType, Note: "Topped up", ActorId: housemateId},
+			wantMerged: true,
+			wantEvents: 1,
+			wantNote:   "Watered\nTopped up",
+			wantActors: []int64{ownerId, housemateId},
+		},
+		{
+			name:       "merged without a note",
+			action:     DedupeMerge,
+			recentAgo:  5 * time.Minute,
+			recentType: waterType,
+			newEvent:   NewEvent{Type: waterType},
+			wantMerged: true,
+			wantEvents: 1,
+			wantNote:   "Watered",
+			wantActors: []int64{ownerId},
+		},
+		{
+			name:       "rejected as duplicate",
+			action:     DedupeReject,
+			recentAgo:  5 * time.Minute,
+			recentType: waterType,
+			newEvent:   NewEvent{Type: waterType, Note: "Topped up"},
+			wantReject: true,
+			wantEvents: 1,
+			wantNote:   "Watered",
+			wantActors: []int64{ownerId},
+		},
+		{
+			name:       "recent event outside window",
+			action:     DedupeReject,
+			recentAgo:  time.Hour,
+			recentType: waterType,
+			newEvent:   NewEvent{Type: waterType, Note: "Soaked"},
+			wantEvents: 2,
+			wantNote:   "Soaked",
+			wantActors: []int64{ownerId},
+		},
+		{
+			name:       "recent event of another type",
+			action:     DedupeReject,
+			recentAgo:  5 * time.Minute,
+			recentType: fertilizeType,
+			newEvent:   NewEvent{Type: waterType, Note: "Soaked"},
+			wantEvents: 2,
+			wantNote:   "Soaked",
+			wantActors: []int64{ownerId},
+		},
+		{
+			name:       "forced",
+			action:     DedupeReject,
+			recentAgo:  5 * time.Minute,
+			recentType: waterType,
+			newEvent:   NewEvent{Type: waterType, Note: "Soaked", Force: true},
+			wantEvents: 2,
+			wantNote:   "Soaked",
+			wantActors: []int64{ownerId},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := &fakeDB{}
+			if test.recentType != 0 {
+				db.events = append(db.events, database.Event{
+					ID:        1,
+					Plantid:   plantId,
+					Eventtype: test.recentType,
+					Note:      "Watered",
+					Timestamp: time.Now().Add(-test.recentAgo),
+					Actorids:  []int64{ownerId},
+				})
+			}
+			listener := &fakeListener{}
+			dedupe := map[int32]Dedupe{
+				waterType:     {Window: window, Action: test.action},
+				fertilizeType: {Window: window, Action: test.action},
+			}
+			service := New(db, db, db, db, dedupe, listener)
+
+			event, merged, err := service.CreateEvent(context.Background(), ownerId, plantId, test.newEvent)
+
+			var duplicate *DuplicateError
+			if test.wantReject {
+				if !errors.As(err, &duplicate) || !errors.Is(err, domainErrors.Conflict) {
+					t.Fatalf("error is %v, want a DuplicateError", err)
+				}
+				event = duplicate.Event
+				if db.committed || !db.rolledBack {
+					t.Error("rejected duplicate wasn't rolled back")
+				}
+				if len(listener.merged) != 0 {
+					t.Error("listeners were told about a rejected duplicate")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("CreateEvent failed: %v", err)
+				}
+				if !slices.Equal(listener.merged, []bool{test.wantMerged}) {
+					t.Errorf("listeners were told merged %v, want [%t]", listener.merged, test.wantMerged)
+				}
+				actorId := test.newEvent.ActorId
+				if actorId == 0 {
+					actorId = ownerId
+				}
+				if !slices.Equal(listener.actorIds, []int64{actorId}) {
+					t.Errorf("listeners were told actors %v, want [%d]", listener.actorIds, actorId)
+				}
+			}
+
+			if merged != test.wantMerged {
+				t.Errorf("merged is %t, want %t", merged, test.wantMerged)
+			}
+			if len(db.events) != test.wantEvents {
+				t.Errorf("%d events stored, want %d", len(db.events), test.wantEvents)
+			}
+			if event.Note != test.wantNote {
+				t.Errorf("note is %q, want %q", event.Note, test.wantNote)
+			}
+			if !slices.Equal(event.Actorids, test.wantActors) {
+				t.Errorf("actors are %v, want %v", event.Actorids, test.wantActors)
+			}
+			if test.newEvent.Force && db.locked {
+				t.Error("forced event still checked for duplicates")
+			}
+			if !test.newEvent.Force && !test.wantReject && !db.committed {
+				t.Error("deduped event wasn't committed")
+			}
+		})
+	}
+}
+
+func TestCreateEventWithoutDedupe(t *testing.T) {
+	db := &fakeDB{events: []database.Event{
+		{ID: 1, Plantid: plantId, Eventtype: waterType, Timestamp: time.Now(), Actorids: []int64{ownerId}},
+	}}
+	service := New(db, db, db, db, map[int32]Dedupe{waterType: {Window: 0, Action: DedupeReject}})
+
+	if _, merged, err := service.CreateEvent(context.Background(), ownerId, plantId, NewEvent{Type: waterType}); err != nil || merged {
+		t.Fatalf("CreateEvent gave merged %t and error %v, want a new event", merged, err)
+	}
+	if db.locked {
+		t.Error("event checked for duplicates with a zero window")
+	}
+	if len(db.events) != 2 {
+		t.Errorf("%d events stored, want 2", len(db.events))
+	}
+}
+
+func TestCreateEventChecksActor(t *testing.T) {
+	db := &fakeDB{}
+	service := New(db, db, db, db, nil)
+
+	_, _, err := service.CreateEvent(context.Background(), ownerId, plantId, NewEvent{Type: waterType, ActorId: 99})
+	if !errors.Is(err, ErrActorNotFound) {
+		t.Errorf("error is %v, want ErrActorNotFound", err)
+	}
+	if len(db.events) != 0 {
+		t.Errorf("%d events stored for an unknown actor, want 0", len(db.events))
+	}
+}
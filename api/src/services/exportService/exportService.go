@@ -0,0 +1,305 @@
+package exportService
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	exportStore "github.com/ReidMason/plant-tracker/src/stores/exportStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+)
+
+// FormatVersion is bumped whenever the shape of exported records changes.
+// Version 2 added the actors of events.
+const FormatVersion = 2
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(format) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// File names and columns of the CSV files inside an exported zip archive
+const (
+	UserFileName   = "user.csv"
+	PlantsFileName = "plants.csv"
+	EventsFileName = "events.csv"
+)
+
+var (
+	UserColumns  = []string{"id", "name", "colour"}
+	PlantColumns = []string{"id", "name"}
+	EventColumns = []string{"id", "plantId", "typeId", "note", "timestamp", "actorIds"}
+)
+
+// ActorIdsSeparator separates the ids in the actorIds column of events.csv
+const ActorIdsSeparator = ";"
+
+type UserRecord struct {
+	Name   string `json:"name"`
+	Colour string `json:"colour"`
+	Id     int64  `json:"id"`
+}
+
+type PlantRecord struct {
+	Name string `json:"name"`
+	Id   int64  `json:"id"`
+}
+
+type EventRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Note      string    `json:"note"`
+	Id        int64     `json:"id"`
+	PlantId   int64     `json:"plantId"`
+	TypeId    int32     `json:"typeId"`
+	// ActorIds are the users who did the care, added in version 2
+	ActorIds []int64 `json:"actorIds"`
+}
+
+// Document is the shape of a JSON export
+type Document struct {
+	ExportedAt time.Time     `json:"exportedAt"`
+	User       UserRecord    `json:"user"`
+	Plants     []PlantRecord `json:"plants"`
+	Events     []EventRecord `json:"events"`
+	Version    int           `json:"version"`
+}
+
+type ExportService interface {
+	Export(ctx context.Context, userId int64, format Format, w io.Writer) error
+}
+
+type exportService struct {
+	exportStore exportStore.ExportStore
+}
+
+func New(exportStore exportStore.ExportStore) *exportService {
+	return &exportService{
+		exportStore: exportStore,
+	}
+}
+
+// Export streams all of a user's plants and events to w. Nothing is written
+// if the user does not exist.
+func (s *exportService) Export(ctx context.Context, userId int64, format Format, w io.Writer) error {
+	ctx, span := tracing.Start(ctx, "exportService.Export")
+	defer span.End()
+
+	user, err := s.exportStore.GetUserById(ctx, userId)
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	switch format {
+	case FormatJSON:
+		return s.exportJSON(ctx, user, w)
+	case FormatCSV:
+		return s.exportCSV(ctx, user, w)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+func (s *exportService) exportJSON(ctx context.Context, user database.User, w io.Writer) error {
+	header, err := json.Marshal(struct {
+		ExportedAt time.Time  `json:"exportedAt"`
+		User       UserRecord `json:"user"`
+		Version    int        `json:"version"`
+	}{
+		ExportedAt: time.Now(),
+		User:       FromStoreUser(user),
+		Version:    FormatVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Splice the plant and event arrays into the header object so that neither
+	// has to be held in memory
+	if _, err := w.Write(header[:len(header)-1]); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, `,"plants":[`); err != nil {
+		return err
+	}
+	plants := newJSONArrayWriter(w)
+	if err := s.exportStore.StreamPlantsByUserId(ctx, user.ID, func(plant database.Plant) error {
+		return plants.write(FromStorePlant(plant))
+	}); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, `],"events":[`); err != nil {
+		return err
+	}
+	events := newJSONArrayWriter(w)
+	if err := s.exportStore.StreamEventsByUserId(ctx, user.ID, func(event database.Event) error {
+		return events.write(FromStoreEvent(event))
+	}); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]}")
+	return err
+}
+
+func (s *exportService) exportCSV(ctx context.Context, user database.User, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	userFile, err := newCSVFile(archive, UserFileName, UserColumns)
+	if err != nil {
+		return err
+	}
+	if err := userFile.Write([]string{formatInt(user.ID), user.Name, user.Colour}); err != nil {
+		return err
+	}
+	userFile.Flush()
+	if err := userFile.Error(); err != nil {
+		return err
+	}
+
+	plantsFile, err := newCSVFile(archive, PlantsFileName, PlantColumns)
+	if err != nil {
+		return err
+	}
+	if err := s.exportStore.StreamPlantsByUserId(ctx, user.ID, func(plant database.Plant) error {
+		return plantsFile.Write([]string{formatInt(plant.ID), plant.Name})
+	}); err != nil {
+		return err
+	}
+	plantsFile.Flush()
+	if err := plantsFile.Error(); err != nil {
+		return err
+	}
+
+	eventsFile, err := newCSVFile(archive, EventsFileName, EventColumns)
+	if err != nil {
+		return err
+	}
+	if err := s.exportStore.StreamEventsByUserId(ctx, user.ID, func(event database.Event) error {
+		return eventsFile.Write([]string{
+			formatInt(event.ID),
+			formatInt(event.Plantid),
+			formatInt(int64(event.Eventtype)),
+			event.Note,
+			event.Timestamp.Format(time.RFC3339Nano),
+			formatIds(event.Actorids),
+		})
+	}); err != nil {
+		return err
+	}
+	eventsFile.Flush()
+	if err := eventsFile.Error(); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func newCSVFile(archive *zip.Writer, name string, columns []string) (*csv.Writer, error) {
+	file, err := archive.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := csv.NewWriter(file)
+	return writer, writer.Write(columns)
+}
+
+func formatInt(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+func formatIds(ids []int64) string {
+	formatted := make([]string, len(ids))
+	for i, id := range ids {
+		formatted[i] = formatInt(id)
+	}
+	return strings.Join(formatted, ActorIdsSeparator)
+}
+
+type jsonArrayWriter struct {
+	w     io.Writer
+	first bool
+}
+
+func newJSONArrayWriter(w io.Writer) *jsonArrayWriter {
+	return &jsonArrayWriter{w: w, first: true}
+}
+
+func (a *jsonArrayWriter) write(v any) error {
+	item, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if !a.first {
+		if _, err := io.WriteString(a.w, ","); err != nil {
+			return err
+		}
+	}
+	a.first = false
+
+	_, err = a.w.Write(item)
+	return err
+}
+
+func FromStoreUser(user database.User) UserRecord {
+	return UserRecord{
+		Id:     user.ID,
+		Name:   user.Name,
+		Colour: user.Colour,
+	}
+}
+
+func FromStorePlant(plant database.Plant) PlantRecord {
+	return PlantRecord{
+		Id:   plant.ID,
+		Name: plant.Name,
+	}
+}
+
+func FromStoreEvent(event database.Event) EventRecord {
+	return EventRecord{
+		Id:        event.ID,
+		PlantId:   event.Plantid,
+		TypeId:    event.Eventtype,
+		Note:      event.Note,
+		Timestamp: event.Timestamp,
+		ActorIds:  event.Actorids,
+	}
+}
+
+type exportError string
+
+func (e exportError) Error() string {
+	return string(e)
+}
+
+const ErrUnsupportedFormat exportError = "unsupported format, expected json or csv"
+
+var ErrUserNotFound = domainErrors.New(domainErrors.NotFound, "User not found")
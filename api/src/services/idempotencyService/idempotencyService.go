@@ -0,0 +1,162 @@
+package idempotencyService
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/logging"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	idempotencyStore "github.com/ReidMason/plant-tracker/src/stores/idempotencyStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, client string, key string, requestHash []byte) (*Response, error)
+	Complete(ctx context.Context, client string, key string, response Response) error
+	Release(ctx context.Context, client string, key string) error
+}
+
+var (
+	// ErrKeyReused is returned when a key is sent again with a different request
+	ErrKeyReused = domainErrors.New(domainErrors.Validation, "Idempotency-Key has already been used for a different request")
+	// ErrKeyInProgress is returned when a key is sent again before the first
+	// request with it has finished
+	ErrKeyInProgress = domainErrors.New(domainErrors.Conflict, "A request with this Idempotency-Key is still being handled, retry shortly")
+)
+
+// Response is what was sent for the first request with a key, to be sent
+// again for repeats of it
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type idempotencyService struct {
+	store       idempotencyStore.IdempotencyStore
+	retention   time.Duration
+	lockTimeout time.Duration
+}
+
+// New creates a service that remembers responses for retention. A request
+// that hasn't completed after lockTimeout is assumed to have been abandoned,
+// e.g. by the server stopping, and its key can be used again. With no
+// lockTimeout the key is held until it expires.
+func New(store idempotencyStore.IdempotencyStore, retention time.Duration, lockTimeout time.Duration) *idempotencyService {
+	if lockTimeout <= 0 || lockTimeout > retention {
+		lockTimeout = retention
+	}
+	return &idempotencyService{
+		store:       store,
+		retention:   retention,
+		lockTimeout: lockTimeout,
+	}
+}
+
+// Begin claims client's key for a request identified by requestHash. It
+// returns nil if the request is new and should be handled, after which
+// Complete or Release must be called, or the response to replay if the
+// request has been handled before.
+func (s *idempotencyService) Begin(ctx context.Context, client string, key string, requestHash []byte) (*Response, error) {
+	ctx, span := tracing.Start(ctx, "idempotencyService.Begin")
+	defer span.End()
+
+	// A key can be released between failing to claim it and reading it back,
+	// in which case the claim is tried again
+	for range 2 {
+		now := time.Now()
+		_, err := s.store.ClaimIdempotencyKey(ctx, database.ClaimIdempotencyKeyParams{
+			Client:          client,
+			Key:             key,
+			RequestHash:     requestHash,
+			ExpiredBefore:   now.Add(-s.retention),
+			AbandonedBefore: now.Add(-s.lockTimeout),
+		})
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, domainErrors.NotFound) {
+			return nil, err
+		}
+
+		existing, err := s.store.GetIdempotencyKey(ctx, database.GetIdempotencyKeyParams{Client: client, Key: key})
+		if err != nil {
+			if errors.Is(err, domainErrors.NotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		if !bytes.Equal(existing.Requesthash, requestHash) {
+			return nil, ErrKeyReused
+		}
+		if !existing.Status.Valid {
+			return nil, ErrKeyInProgress
+		}
+
+		response := &Response{Status: int(existing.Status.Int32), Body: existing.Body}
+		if err := json.Unmarshal(existing.Headers, &response.Header); err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+
+	return nil, ErrKeyInProgress
+}
+
+// Complete stores the response to a request begun with key so repeats of it
+// are answered the same way
+func (s *idempotencyService) Complete(ctx context.Context, client string, key string, response Response) error {
+	ctx, span := tracing.Start(ctx, "idempotencyService.Complete")
+	defer span.End()
+
+	headers, err := json.Marshal(response.Header)
+	if err != nil {
+		return err
+	}
+
+	return s.store.CompleteIdempotencyKey(ctx, database.CompleteIdempotencyKeyParams{
+		Client:  client,
+		Key:     key,
+		Status:  pgtype.Int4{Int32: int32(response.Status), Valid: true},
+		Headers: headers,
+		Body:    response.Body,
+	})
+}
+
+// Release forgets a key whose request failed in a way that is worth retrying
+func (s *idempotencyService) Release(ctx context.Context, client string, key string) error {
+	ctx, span := tracing.Start(ctx, "idempotencyService.Release")
+	defer span.End()
+
+	return s.store.ReleaseIdempotencyKey(ctx, database.ReleaseIdempotencyKeyParams{Client: client, Key: key})
+}
+
+// Run deletes expired keys every interval until ctx is cancelled
+func (s *idempotencyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := s.store.DeleteExpiredIdempotencyKeys(ctx, time.Now().Add(-s.retention))
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.FromContext(ctx).Error("Failed to delete expired idempotency keys", "error", err)
+			}
+			continue
+		}
+		logging.FromContext(ctx).Debug("Deleted expired idempotency keys", "count", deleted)
+	}
+}
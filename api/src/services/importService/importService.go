@@ -0,0 +1,313 @@
+package importService
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/logging"
+	"github.com/ReidMason/plant-tracker/src/services/achievementsService"
+	"github.com/ReidMason/plant-tracker/src/services/exportService"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/ReidMason/plant-tracker/src/stores/dbErrors"
+	importStore "github.com/ReidMason/plant-tracker/src/stores/importStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+// TxStarter begins the transaction an import is applied in
+type TxStarter interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+type ImportService interface {
+	Import(ctx context.Context, userId int64, request Request) (Report, error)
+}
+
+// Request describes an uploaded file in one of the export formats
+type Request struct {
+	File    io.ReaderAt
+	Mapping Mapping
+	Format  exportService.Format
+	Size    int64
+	DryRun  bool
+}
+
+// Report describes what an import created, or would create for a dry run
+type Report struct {
+	PlantsCreated   []exportService.PlantRecord
+	PlantsExisting  []exportService.PlantRecord
+	EventsCreated   int
+	EventsDuplicate int
+	DryRun          bool
+}
+
+// ValidationError lists every problem found in an uploaded file
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid import: %s", strings.Join(e.Problems, "; "))
+}
+
+// AchievementsEvaluator awards achievements for the care an import recorded
+type AchievementsEvaluator interface {
+	EvaluateAchievements(ctx context.Context, userId int64) ([]achievementsService.Achievement, error)
+}
+
+type importService struct {
+	db           TxStarter
+	importStore  importStore.ImportStore
+	achievements AchievementsEvaluator
+}
+
+// New creates the service. achievements may be nil if they are turned off.
+func New(db TxStarter, importStore importStore.ImportStore, achievements AchievementsEvaluator) *importService {
+	return &importService{
+		db:           db,
+		importStore:  importStore,
+		achievements: achievements,
+	}
+}
+
+// Import applies the uploaded plants and events in a single transaction. Dry
+// runs only read, so plants they would create have no ID in the report.
+func (s *importService) Import(ctx context.Context, userId int64, request Request) (Report, error) {
+	ctx, span := tracing.Start(ctx, "importService.Import")
+	defer span.End()
+
+	records, err := parse(request)
+	if err != nil {
+		return Report{}, err
+	}
+
+	if _, err := s.importStore.GetUserById(ctx, userId); err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return Report{}, ErrUserNotFound
+		}
+		return Report{}, err
+	}
+
+	if request.DryRun {
+		report, _, err := apply(ctx, s.importStore, userId, records, true)
+		report.DryRun = true
+		return report, err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	report, credited, err := apply(ctx, database.New(dbErrors.Wrap(tx)), userId, records, false)
+	if err != nil {
+		return Report{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Report{}, err
+	}
+
+	// Achievements are evaluated once for the whole import rather than per
+	// event, for the owner whose streaks may have changed and everyone
+	// credited with care. A failure doesn't undo the import.
+	if s.achievements != nil && report.EventsCreated > 0 {
+		for _, id := range credited {
+			if _, err := s.achievements.EvaluateAchievements(ctx, id); err != nil {
+				logging.FromContext(ctx).Error("Failed to evaluate achievements after import", "userId", id, "error", err)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// apply creates the plants and events that don't exist yet. A dry run counts
+// them without creating them. Events are inserted in one batch so each plant
+// is only touched once. credited lists the importing user and everyone
+// credited with the created events.
+func apply(ctx context.Context, q importStore.ImportStore, userId int64, records parsedRecords, dryRun bool) (report Report, credited []int64, err error) {
+	report = Report{
+		PlantsCreated:  make([]exportService.PlantRecord, 0),
+		PlantsExisting: make([]exportService.PlantRecord, 0),
+	}
+
+	if err := resolveActors(ctx, q, userId, records); err != nil {
+		return Report{}, nil, err
+	}
+
+	existingPlants, err := q.GetPlantsByUserId(ctx, userId)
+	if err != nil {
+		return Report{}, nil, err
+	}
+
+	// Plants in the file are told apart by their id in it, but can only be
+	// matched to existing plants by name. Plants sharing a name are paired up
+	// in ID order, so re-importing an export with two plants of the same name
+	// matches each to its own plant.
+	slices.SortFunc(existingPlants, func(a, b database.Plant) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	existingByName := make(map[string][]database.Plant, len(existingPlants))
+	for _, plant := range existingPlants {
+		key := plantKey(plant.Name)
+		existingByName[key] = append(existingByName[key], plant)
+	}
+
+	// Plants a dry run would create resolve to ID 0
+	plantIds := make(map[string]int64, len(records.plants))
+	for _, plant := range records.plants {
+		key := plantKey(plant.name)
+		if matches := existingByName[key]; len(matches) > 0 {
+			existingByName[key] = matches[1:]
+			report.PlantsExisting = append(report.PlantsExisting, exportService.FromStorePlant(matches[0]))
+			plantIds[plant.ref] = matches[0].ID
+			continue
+		}
+
+		if dryRun {
+			report.PlantsCreated = append(report.PlantsCreated, exportService.PlantRecord{Name: strings.TrimSpace(plant.name)})
+			plantIds[plant.ref] = 0
+			continue
+		}
+
+		created, err := q.CreatePlant(ctx, database.CreatePlantParams{
+			Name:   strings.TrimSpace(plant.name),
+			Userid: userId,
+		})
+		if err != nil {
+			return Report{}, nil, err
+		}
+		report.PlantsCreated = append(report.PlantsCreated, exportService.FromStorePlant(created))
+		plantIds[plant.ref] = created.ID
+	}
+
+	// Events are duplicates when a plant already has an event of the same type
+	// at the same instant, or an earlier row in the file does
+	eventKeys := make(map[string]map[string]struct{})
+	loadEventKeys := func(plantRef string) (map[string]struct{}, error) {
+		if keys, ok := eventKeys[plantRef]; ok {
+			return keys, nil
+		}
+
+		// A plant that doesn't exist yet has no events
+		keys := make(map[string]struct{})
+		eventKeys[plantRef] = keys
+		plantId := plantIds[plantRef]
+		if plantId == 0 {
+			return keys, nil
+		}
+
+		events, err := q.GetEventsByPlantId(ctx, plantId)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			keys[eventKey(event.Eventtype, event.Timestamp)] = struct{}{}
+		}
+		return keys, nil
+	}
+
+	credited = []int64{userId}
+	batch := make([]database.CreateEventsParams, 0)
+	for _, event := range records.events {
+		keys, err := loadEventKeys(event.plantRef)
+		if err != nil {
+			return Report{}, nil, err
+		}
+
+		key := eventKey(event.typeId, event.timestamp)
+		if _, ok := keys[key]; ok {
+			report.EventsDuplicate++
+			continue
+		}
+
+		keys[key] = struct{}{}
+		batch = append(batch, database.CreateEventsParams{
+			Plantid:   plantIds[event.plantRef],
+			Eventtype: event.typeId,
+			Note:      event.note,
+			Timestamp: event.timestamp,
+			Actorids:  event.actorIds,
+		})
+		for _, actorId := range event.actorIds {
+			if !slices.Contains(credited, actorId) {
+				credited = append(credited, actorId)
+			}
+		}
+	}
+	report.EventsCreated = len(batch)
+
+	if !dryRun && len(batch) > 0 {
+		if _, err := q.CreateEvents(ctx, batch); err != nil {
+			return Report{}, nil, err
+		}
+	}
+
+	return report, credited, nil
+}
+
+// resolveActors replaces the exported user's id in the events' actorIds with
+// the importing user's, credits the importing user with events that have no
+// actors, and checks every other actor is an existing user
+func resolveActors(ctx context.Context, q importStore.ImportStore, userId int64, records parsedRecords) error {
+	checked := map[int64]bool{userId: true}
+	problems := make([]string, 0)
+	for i, event := range records.events {
+		actorIds := make([]int64, 0, max(1, len(event.actorIds)))
+		for _, actorId := range event.actorIds {
+			if actorId == records.sourceUserId {
+				actorId = userId
+			}
+			if slices.Contains(actorIds, actorId) {
+				continue
+			}
+
+			exists, ok := checked[actorId]
+			if !ok {
+				_, err := q.GetUserById(ctx, actorId)
+				if err != nil && !errors.Is(err, domainErrors.NotFound) {
+					return err
+				}
+				exists = err == nil
+				checked[actorId] = exists
+				if !exists && len(problems) < maxProblems {
+					problems = append(problems, fmt.Sprintf("unknown actor id %d", actorId))
+				}
+			}
+			if exists {
+				actorIds = append(actorIds, actorId)
+			}
+		}
+
+		if len(actorIds) == 0 {
+			actorIds = append(actorIds, userId)
+		}
+		records.events[i].actorIds = actorIds
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func plantKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func eventKey(eventType int32, timestamp time.Time) string {
+	// Postgres stores microseconds so compare at that precision
+	return fmt.Sprintf("%d|%d", eventType, timestamp.UnixMicro())
+}
+
+var ErrUserNotFound = domainErrors.New(domainErrors.NotFound, "User not found")
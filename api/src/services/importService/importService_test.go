@@ -0,0 +1,198 @@
+package importService
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/services/exportService"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+)
+
+type fakeStore struct {
+	users   []int64
+	plants  []database.Plant
+	events  []database.Event
+	batches int
+}
+
+func (f *fakeStore) GetUserById(ctx context.Context, id int64) (database.User, error) {
+	if f.users != nil && !slices.Contains(f.users, id) {
+		return database.User{}, domainErrors.New(domainErrors.NotFound, "User not found")
+	}
+	return database.User{ID: id}, nil
+}
+
+func (f *fakeStore) GetPlantsByUserId(ctx context.Context, userId int64) ([]database.Plant, error) {
+	return append([]database.Plant(nil), f.plants...), nil
+}
+
+func (f *fakeStore) CreatePlant(ctx context.Context, arg database.CreatePlantParams) (database.Plant, error) {
+	plant := database.Plant{ID: int64(len(f.plants) + 1), Name: arg.Name, Userid: arg.Userid}
+	f.plants = append(f.plants, plant)
+	return plant, nil
+}
+
+func (f *fakeStore) GetEventsByPlantId(ctx context.Context, plantId int64) ([]database.Event, error) {
+	events := make([]database.Event, 0)
+	for _, event := range f.events {
+		if event.Plantid == plantId {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (f *fakeStore) CreateEvents(ctx context.Context, arg []database.CreateEventsParams) (int64, error) {
+	f.batches++
+	for _, params := range arg {
+		f.events = append(f.events, database.Event{
+			ID:        int64(len(f.events) + 1),
+			Plantid:   params.Plantid,
+			Eventtype: params.Eventtype,
+			Note:      params.Note,
+			Timestamp: params.Timestamp,
+			Actorids:  params.Actorids,
+		})
+	}
+	return int64(len(arg)), nil
+}
+
+const sameNameExport = `{
+	"version": 1,
+	"user": {"id": 9, "name": "Sam", "colour": "#000000"},
+	"plants": [{"id": 1, "name": "Fern"}, {"id": 2, "name": "Fern"}],
+	"events": [
+		{"id": 1, "plantId": 1, "typeId": 1, "timestamp": "2026-01-01T00:00:00Z"},
+		{"id": 2, "plantId": 2, "typeId": 1, "timestamp": "2026-01-01T00:00:00Z"},
+		{"id": 3, "plantId": 2, "typeId": 2, "timestamp": "2026-01-02T00:00:00Z"}
+	]
+}`
+
+func parseJSON(t *testing.T, document string) parsedRecords {
+	t.Helper()
+	records, err := parse(Request{
+		File:   strings.NewReader(document),
+		Size:   int64(len(document)),
+		Format: exportService.FormatJSON,
+	})
+	if err != nil {
+		t.Fatalf("failed to parse: %s", err)
+	}
+	return records
+}
+
+func TestApplyKeepsPlantsWithTheSameNameApart(t *testing.T) {
+	store := &fakeStore{}
+	records := parseJSON(t, sameNameExport)
+
+	report, _, err := apply(context.Background(), store, 1, records, false)
+	if err != nil {
+		t.Fatalf("failed to apply: %s", err)
+	}
+	if len(report.PlantsCreated) != 2 || report.EventsCreated != 3 {
+		t.Fatalf("created %d plants and %d events, want 2 and 3", len(report.PlantsCreated), report.EventsCreated)
+	}
+
+	perPlant := map[int64]int{}
+	for _, event := range store.events {
+		perPlant[event.Plantid]++
+	}
+	if perPlant[1] != 1 || perPlant[2] != 2 {
+		t.Errorf("events per plant are %v, want 1 for plant 1 and 2 for plant 2", perPlant)
+	}
+	if store.batches != 1 {
+		t.Errorf("events were inserted in %d batches, want 1", store.batches)
+	}
+
+	// Importing the same file again matches each plant to the one it created
+	report, _, err = apply(context.Background(), store, 1, records, false)
+	if err != nil {
+		t.Fatalf("failed to apply again: %s", err)
+	}
+	if len(report.PlantsExisting) != 2 || len(report.PlantsCreated) != 0 {
+		t.Errorf("re-import matched %d plants and created %d, want 2 and 0", len(report.PlantsExisting), len(report.PlantsCreated))
+	}
+	if report.EventsCreated != 0 || report.EventsDuplicate != 3 {
+		t.Errorf("re-import created %d events with %d duplicates, want 0 and 3", report.EventsCreated, report.EventsDuplicate)
+	}
+}
+
+func TestApplyDryRunDoesNotWrite(t *testing.T) {
+	store := &fakeStore{plants: []database.Plant{{ID: 1, Name: "Fern"}}}
+	store.events = []database.Event{{ID: 1, Plantid: 1, Eventtype: 1, Timestamp: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}}
+
+	report, _, err := apply(context.Background(), store, 1, parseJSON(t, sameNameExport), true)
+	if err != nil {
+		t.Fatalf("failed to apply: %s", err)
+	}
+	if len(store.plants) != 1 || len(store.events) != 1 || store.batches != 0 {
+		t.Errorf("dry run wrote %d plants and %d events", len(store.plants)-1, len(store.events)-1)
+	}
+	if len(report.PlantsExisting) != 1 || len(report.PlantsCreated) != 1 || report.PlantsCreated[0].Id != 0 {
+		t.Errorf("dry run reported %v existing and %v created, want the first Fern existing and the second created without an ID", report.PlantsExisting, report.PlantsCreated)
+	}
+	if report.EventsCreated != 2 || report.EventsDuplicate != 1 {
+		t.Errorf("dry run counted %d events and %d duplicates, want 2 and 1", report.EventsCreated, report.EventsDuplicate)
+	}
+}
+
+func TestParseRejectsDuplicatePlantIds(t *testing.T) {
+	document := `{"version": 1, "plants": [{"id": 1, "name": "Fern"}, {"id": 1, "name": "Cactus"}], "events": []}`
+	_, err := parse(Request{File: strings.NewReader(document), Size: int64(len(document)), Format: exportService.FormatJSON})
+	if err == nil || !strings.Contains(err.Error(), "duplicate id 1") {
+		t.Errorf("parsing duplicate plant ids returned %v, want a duplicate id problem", err)
+	}
+}
+
+func TestApplyCreditsActors(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		actors  string
+		want    []int64
+		invalid bool
+	}{
+		{name: "version 1 ignores actors", version: 1, actors: "[4]", want: []int64{1}},
+		{name: "no actors", version: 2, actors: "[]", want: []int64{1}},
+		{name: "exported user is the importing user", version: 2, actors: "[9]", want: []int64{1}},
+		{name: "housemate", version: 2, actors: "[9, 4]", want: []int64{1, 4}},
+		{name: "unknown actor", version: 2, actors: "[5]", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := &fakeStore{users: []int64{1, 4}}
+			document := fmt.Sprintf(`{
+				"version": %d,
+				"user": {"id": 9, "name": "Sam", "colour": "#000000"},
+				"plants": [{"id": 1, "name": "Fern"}],
+				"events": [{"id": 1, "plantId": 1, "typeId": 1, "timestamp": "2026-01-01T00:00:00Z", "actorIds": %s}]
+			}`, test.version, test.actors)
+
+			_, credited, err := apply(context.Background(), store, 1, parseJSON(t, document), false)
+			if test.invalid {
+				var validationError *ValidationError
+				if !errors.As(err, &validationError) {
+					t.Fatalf("apply returned %v, want a ValidationError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to apply: %s", err)
+			}
+
+			if !slices.Equal(store.events[0].Actorids, test.want) {
+				t.Errorf("event has actors %v, want %v", store.events[0].Actorids, test.want)
+			}
+			if !slices.Equal(credited, test.want) {
+				t.Errorf("credited %v, want %v", credited, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,541 @@
+package importService
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/services/exportService"
+)
+
+// Mapping tells the importer which source columns hold each field, for files
+// coming from spreadsheets or other apps. Keys are the column names used by
+// exports (e.g. "timestamp") and values are the headers in the uploaded file.
+type Mapping struct {
+	Plants          map[string]string `json:"plants"`
+	Events          map[string]string `json:"events"`
+	EventTypes      map[string]int32  `json:"eventTypes"`
+	TimestampLayout string            `json:"timestampLayout"`
+}
+
+// Columns understood by the importer. Events may reference their plant by the
+// id used in plants.csv or directly by name, and their type by id or name.
+var (
+	plantColumns = []string{"id", "name"}
+	eventColumns = []string{"id", "plantId", "plantName", "typeId", "type", "note", "timestamp", "actorIds"}
+)
+
+var eventTypeNames = map[string]int32{
+	"water":     1,
+	"fertilize": 2,
+}
+
+// maxProblems stops a badly mapped file from producing thousands of errors
+const maxProblems = 50
+
+// parsedPlant is a plant in the file, identified by ref: its id in the file
+// if it has one, otherwise its name
+type parsedPlant struct {
+	ref  string
+	name string
+}
+
+type parsedEvent struct {
+	timestamp time.Time
+	plantRef  string
+	note      string
+	// actorIds are as in the file, which files from before export version 2
+	// don't have, in which case the care is credited to the importing user
+	actorIds []int64
+	typeId   int32
+}
+
+type parsedRecords struct {
+	plants []parsedPlant
+	events []parsedEvent
+	// sourceUserId is the id of the exported user in the file, if known, which
+	// is the importing user in actorIds
+	sourceUserId int64
+	// refs holds the ref of every plant and names the ref of the first plant
+	// with each name, for events that reference their plant by name
+	refs  map[string]struct{}
+	names map[string]string
+}
+
+func newParsedRecords() parsedRecords {
+	return parsedRecords{
+		plants: make([]parsedPlant, 0),
+		events: make([]parsedEvent, 0),
+		refs:   make(map[string]struct{}),
+		names:  make(map[string]string),
+	}
+}
+
+// addPlant adds a plant, returning false if one with the same ref was added
+// already
+func (r *parsedRecords) addPlant(ref string, name string) bool {
+	if _, ok := r.refs[ref]; ok {
+		return false
+	}
+	r.refs[ref] = struct{}{}
+	if _, ok := r.names[plantKey(name)]; !ok {
+		r.names[plantKey(name)] = ref
+	}
+	r.plants = append(r.plants, parsedPlant{ref: ref, name: name})
+	return true
+}
+
+func (r *parsedRecords) hasPlant(ref string) bool {
+	_, ok := r.refs[ref]
+	return ok
+}
+
+// plantByName returns the ref of the first plant with name, adding a plant
+// for events whose plant isn't listed in the file
+func (r *parsedRecords) plantByName(name string) string {
+	if ref, ok := r.names[plantKey(name)]; ok {
+		return ref
+	}
+	ref := nameRef(name)
+	r.addPlant(ref, name)
+	return ref
+}
+
+func idRef(id string) string {
+	return "id:" + strings.TrimSpace(id)
+}
+
+func nameRef(name string) string {
+	return "name:" + plantKey(name)
+}
+
+type parser struct {
+	mapping  Mapping
+	problems []string
+}
+
+func parse(request Request) (parsedRecords, error) {
+	p := &parser{mapping: request.Mapping}
+	if err := p.validateMapping(); err != nil {
+		return parsedRecords{}, err
+	}
+
+	var records parsedRecords
+	var err error
+	switch request.Format {
+	case exportService.FormatJSON:
+		records, err = p.parseJSON(io.NewSectionReader(request.File, 0, request.Size))
+	case exportService.FormatCSV:
+		records, err = p.parseCSV(request.File, request.Size)
+	default:
+		return parsedRecords{}, exportService.ErrUnsupportedFormat
+	}
+	if err != nil {
+		return parsedRecords{}, err
+	}
+
+	if len(p.problems) > 0 {
+		return parsedRecords{}, &ValidationError{Problems: p.problems}
+	}
+
+	return records, nil
+}
+
+func (p *parser) problem(format string, args ...any) {
+	if len(p.problems) < maxProblems {
+		p.problems = append(p.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+func (p *parser) validateMapping() error {
+	problems := make([]string, 0)
+	for column := range p.mapping.Plants {
+		if !slices.Contains(plantColumns, column) {
+			problems = append(problems, fmt.Sprintf("unknown plant column %q in mapping", column))
+		}
+	}
+
+	for column := range p.mapping.Events {
+		if !slices.Contains(eventColumns, column) {
+			problems = append(problems, fmt.Sprintf("unknown event column %q in mapping", column))
+		}
+	}
+
+	for name, typeId := range p.mapping.EventTypes {
+		if !validEventType(typeId) {
+			problems = append(problems, fmt.Sprintf("event type %q maps to unknown type id %d", name, typeId))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+func (p *parser) parseJSON(r io.Reader) (parsedRecords, error) {
+	var document exportService.Document
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return parsedRecords{}, &ValidationError{Problems: []string{fmt.Sprintf("failed to parse JSON: %s", err)}}
+	}
+
+	if document.Version > exportService.FormatVersion {
+		return parsedRecords{}, &ValidationError{Problems: []string{fmt.Sprintf("unsupported export version %d", document.Version)}}
+	}
+
+	records := newParsedRecords()
+	records.sourceUserId = document.User.Id
+	for i, plant := range document.Plants {
+		if strings.TrimSpace(plant.Name) == "" {
+			p.problem("plants[%d]: name is required", i)
+			continue
+		}
+		if !records.addPlant(idRef(strconv.FormatInt(plant.Id, 10)), plant.Name) {
+			p.problem("plants[%d]: duplicate id %d", i, plant.Id)
+		}
+	}
+
+	for i, event := range document.Events {
+		plantRef := idRef(strconv.FormatInt(event.PlantId, 10))
+		if !records.hasPlant(plantRef) {
+			p.problem("events[%d]: unknown plant id %d", i, event.PlantId)
+			continue
+		}
+
+		if !validEventType(event.TypeId) {
+			p.problem("events[%d]: unknown event type %d", i, event.TypeId)
+			continue
+		}
+
+		if event.Timestamp.IsZero() {
+			p.problem("events[%d]: timestamp is required", i)
+			continue
+		}
+
+		var actorIds []int64
+		if document.Version >= 2 {
+			actorIds = event.ActorIds
+		}
+		if slices.ContainsFunc(actorIds, func(id int64) bool { return id <= 0 }) {
+			p.problem("events[%d]: invalid actorIds %v", i, actorIds)
+			continue
+		}
+
+		records.events = append(records.events, parsedEvent{
+			plantRef:  plantRef,
+			typeId:    event.TypeId,
+			note:      event.Note,
+			timestamp: event.Timestamp,
+			actorIds:  actorIds,
+		})
+	}
+
+	return records, nil
+}
+
+// parseCSV accepts either a zip archive as produced by the CSV export or a
+// single CSV file of events that reference plants by name
+func (p *parser) parseCSV(file io.ReaderAt, size int64) (parsedRecords, error) {
+	magic := make([]byte, 4)
+	if _, err := file.ReadAt(magic, 0); err != nil && !errors.Is(err, io.EOF) {
+		return parsedRecords{}, err
+	}
+
+	if !bytes.Equal(magic, []byte("PK\x03\x04")) {
+		records := newParsedRecords()
+		err := p.parseEventsCSV(io.NewSectionReader(file, 0, size), "events", false, &records)
+		return records, err
+	}
+
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		return parsedRecords{}, &ValidationError{Problems: []string{fmt.Sprintf("failed to read zip archive: %s", err)}}
+	}
+
+	records := newParsedRecords()
+	userFile, err := archive.Open(exportService.UserFileName)
+	if err == nil {
+		err = p.parseUserCSV(userFile, &records)
+		userFile.Close()
+		if err != nil {
+			return parsedRecords{}, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return parsedRecords{}, err
+	}
+
+	plantsFile, err := archive.Open(exportService.PlantsFileName)
+	if err == nil {
+		err = p.parsePlantsCSV(plantsFile, &records)
+		plantsFile.Close()
+		if err != nil {
+			return parsedRecords{}, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return parsedRecords{}, err
+	}
+
+	eventsFile, err := archive.Open(exportService.EventsFileName)
+	if err == nil {
+		err = p.parseEventsCSV(eventsFile, exportService.EventsFileName, true, &records)
+		eventsFile.Close()
+		if err != nil {
+			return parsedRecords{}, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return parsedRecords{}, err
+	}
+
+	if len(records.plants) == 0 && len(records.events) == 0 {
+		p.problem("zip archive contains no %s or %s", exportService.PlantsFileName, exportService.EventsFileName)
+	}
+
+	return records, nil
+}
+
+// parseUserCSV reads the id of the exported user, which actorIds refer to
+func (p *parser) parseUserCSV(r io.Reader, records *parsedRecords) error {
+	table, err := p.readCSV(r, exportService.UserFileName, nil)
+	if err != nil {
+		return err
+	}
+
+	return table.each(func(line int, row csvRow) {
+		id, err := strconv.ParseInt(strings.TrimSpace(row.get("id")), 10, 64)
+		if err != nil {
+			p.problem("%s:%d: invalid id %q", exportService.UserFileName, line, row.get("id"))
+			return
+		}
+		records.sourceUserId = id
+	})
+}
+
+func (p *parser) parsePlantsCSV(r io.Reader, records *parsedRecords) error {
+	table, err := p.readCSV(r, exportService.PlantsFileName, p.mapping.Plants)
+	if err != nil {
+		return err
+	}
+
+	if !table.has("name") {
+		p.problem("%s: missing column %q", exportService.PlantsFileName, table.header("name"))
+		return nil
+	}
+
+	return table.each(func(line int, row csvRow) {
+		name := row.get("name")
+		if strings.TrimSpace(name) == "" {
+			p.problem("%s:%d: name is required", exportService.PlantsFileName, line)
+			return
+		}
+
+		// Plants without an id can only be told apart by name
+		id := row.get("id")
+		if strings.TrimSpace(id) == "" {
+			records.addPlant(nameRef(name), name)
+			return
+		}
+		if !records.addPlant(idRef(id), name) {
+			p.problem("%s:%d: duplicate id %q", exportService.PlantsFileName, line, id)
+		}
+	})
+}
+
+// parseEventsCSV resolves plant ids to the plants read from the archive's
+// plants file. Files that were not part of an archive can only reference
+// plants by name.
+func (p *parser) parseEventsCSV(r io.Reader, fileName string, inArchive bool, records *parsedRecords) error {
+	table, err := p.readCSV(r, fileName, p.mapping.Events)
+	if err != nil {
+		return err
+	}
+
+	if !table.has("timestamp") {
+		p.problem("%s: missing column %q", fileName, table.header("timestamp"))
+	}
+	if !table.has("plantName") && (!inArchive || !table.has("plantId")) {
+		p.problem("%s: missing column %q", fileName, table.header("plantName"))
+	}
+	if !table.has("typeId") && !table.has("type") {
+		p.problem("%s: missing column %q or %q", fileName, table.header("typeId"), table.header("type"))
+	}
+	if len(p.problems) > 0 {
+		return nil
+	}
+
+	layout := p.mapping.TimestampLayout
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+
+	return table.each(func(line int, row csvRow) {
+		var plantRef string
+		if plantId := row.get("plantId"); inArchive && strings.TrimSpace(plantId) != "" {
+			plantRef = idRef(plantId)
+			if !records.hasPlant(plantRef) {
+				p.problem("%s:%d: unknown plant id %q", fileName, line, plantId)
+				return
+			}
+		} else if plantName := row.get("plantName"); strings.TrimSpace(plantName) != "" {
+			plantRef = records.plantByName(plantName)
+		} else {
+			p.problem("%s:%d: unknown plant", fileName, line)
+			return
+		}
+
+		typeId, err := p.resolveEventType(row.get("typeId"), row.get("type"))
+		if err != nil {
+			p.problem("%s:%d: %s", fileName, line, err)
+			return
+		}
+
+		timestamp, err := time.Parse(layout, strings.TrimSpace(row.get("timestamp")))
+		if err != nil {
+			p.problem("%s:%d: invalid timestamp %q", fileName, line, row.get("timestamp"))
+			return
+		}
+
+		actorIds, err := parseIds(row.get("actorIds"))
+		if err != nil {
+			p.problem("%s:%d: invalid actorIds %q", fileName, line, row.get("actorIds"))
+			return
+		}
+
+		records.events = append(records.events, parsedEvent{
+			plantRef:  plantRef,
+			typeId:    typeId,
+			note:      row.get("note"),
+			timestamp: timestamp,
+			actorIds:  actorIds,
+		})
+	})
+}
+
+func (p *parser) resolveEventType(typeId string, typeName string) (int32, error) {
+	if typeId = strings.TrimSpace(typeId); typeId != "" {
+		id, err := strconv.ParseInt(typeId, 10, 32)
+		if err != nil || !validEventType(int32(id)) {
+			return 0, fmt.Errorf("unknown event type %q", typeId)
+		}
+		return int32(id), nil
+	}
+
+	typeName = strings.TrimSpace(typeName)
+	if id, ok := p.mapping.EventTypes[typeName]; ok {
+		return id, nil
+	}
+	for name, id := range p.mapping.EventTypes {
+		if strings.EqualFold(name, typeName) {
+			return id, nil
+		}
+	}
+	if id, ok := eventTypeNames[strings.ToLower(typeName)]; ok {
+		return id, nil
+	}
+
+	return 0, fmt.Errorf("unknown event type %q", typeName)
+}
+
+type csvTable struct {
+	reader   *csv.Reader
+	mapping  map[string]string
+	columns  map[string]int
+	fileName string
+}
+
+type csvRow struct {
+	table  *csvTable
+	record []string
+}
+
+func (p *parser) readCSV(r io.Reader, fileName string, mapping map[string]string) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("%s: failed to read header: %s", fileName, err)}}
+	}
+
+	columns := make(map[string]int, len(headers))
+	for i, header := range headers {
+		// Spreadsheet exports often start with a byte order mark
+		columns[strings.TrimPrefix(strings.TrimSpace(header), "\ufeff")] = i
+	}
+
+	return &csvTable{
+		reader:   reader,
+		mapping:  mapping,
+		columns:  columns,
+		fileName: fileName,
+	}, nil
+}
+
+// header returns the source header for one of the importer's column names
+func (t *csvTable) header(column string) string {
+	if header, ok := t.mapping[column]; ok {
+		return header
+	}
+	return column
+}
+
+func (t *csvTable) has(column string) bool {
+	_, ok := t.columns[t.header(column)]
+	return ok
+}
+
+func (t *csvTable) each(fn func(line int, row csvRow)) error {
+	for {
+		record, err := t.reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return &ValidationError{Problems: []string{fmt.Sprintf("%s: %s", t.fileName, err)}}
+		}
+
+		line, _ := t.reader.FieldPos(0)
+		fn(line, csvRow{table: t, record: record})
+	}
+}
+
+func (r csvRow) get(column string) string {
+	i, ok := r.table.columns[r.table.header(column)]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return r.record[i]
+}
+
+// parseIds reads ids joined with exportService.ActorIdsSeparator, returning
+// nil for an empty value
+func parseIds(value string) ([]int64, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, exportService.ActorIdsSeparator)
+	ids := make([]int64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func validEventType(typeId int32) bool {
+	return typeId == 1 || typeId == 2
+}
@@ -2,20 +2,64 @@ package plantsService
 
 import (
 	"context"
-	"database/sql"
 	"errors"
+	"slices"
 	"time"
 
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
 	"github.com/ReidMason/plant-tracker/src/services/eventsService"
 	"github.com/ReidMason/plant-tracker/src/stores/database"
 	plantstore "github.com/ReidMason/plant-tracker/src/stores/plantsStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type GetPlantsService interface {
 	GetPlantsByUserId(ctx context.Context, userId int64) ([]Plant, error)
-	GetPlantById(ctx context.Context, id int64) (Plant, error)
+	GetPlantById(ctx context.Context, userId int64, id int64) (Plant, error)
 	CreatePlant(ctx context.Context, name string, userId int64) (database.Plant, error)
-	UpdatePlant(ctx context.Context, id int64, name string) (Plant, error)
+	UpdatePlant(ctx context.Context, userId int64, id int64, precondition Precondition, name string) (Plant, error)
+	PatchPlant(ctx context.Context, userId int64, id int64, precondition Precondition, patch Patch) (Plant, error)
+	DeletePlant(ctx context.Context, userId int64, id int64, precondition Precondition) error
+	GetPlantsVersion(ctx context.Context, userId int64) (database.GetPlantsVersionByUserIdRow, error)
+	GetPlantVersion(ctx context.Context, userId int64, id int64) (Version, error)
+}
+
+var (
+	// ErrPlantNotFound is returned for plants that don't exist or belong to another user
+	ErrPlantNotFound = domainErrors.New(domainErrors.NotFound, "Plant not found")
+	// ErrPlantChanged is returned for writes made against a version of the
+	// plant that is no longer current
+	ErrPlantChanged = domainErrors.New(domainErrors.PreconditionFailed, "Plant has changed since it was read, fetch it again and retry")
+)
+
+// Version identifies a state of a plant. Number goes up with every change to
+// the plant's own fields and Modified is when the latest change to it or its
+// events was made.
+type Version struct {
+	Number   int64
+	Modified time.Time
+}
+
+// Precondition is what a write expects the plant's version to be. Any accepts
+// whichever version is current.
+type Precondition struct {
+	Any      bool
+	Versions []int64
+}
+
+// Allows reports whether the precondition holds for version
+func (p Precondition) Allows(version int64) bool {
+	return p.Any || slices.Contains(p.Versions, version)
+}
+
+// Patch is a partial update to a plant. Nil fields are left unchanged.
+type Patch struct {
+	Name *string
+}
+
+func (p Patch) empty() bool {
+	return p.Name == nil
 }
 
 type PlantsService struct {
@@ -28,14 +72,18 @@ type Plant struct {
 	LatestFertilizerEvent database.Event
 	NextWaterDue          time.Time
 	NextFertilizerDue     time.Time
+	Modified              time.Time
 	Name                  string
 	Id                    int64
+	Version               int64
 }
 
 func DatabasePlantToPlantModel(plant database.Plant) Plant {
 	return Plant{
 		Id:                    plant.ID,
 		Name:                  plant.Name,
+		Version:               plant.Version,
+		Modified:              plant.Updatedat,
 		LatestWaterEvent:      database.Event{},
 		LatestFertilizerEvent: database.Event{},
 	}
@@ -49,6 +97,9 @@ func New(plantsStore plantstore.PlantsStore, eventsStore eventsService.EventsSer
 }
 
 func (p *PlantsService) GetPlantsByUserId(ctx context.Context, userId int64) ([]Plant, error) {
+	ctx, span := tracing.Start(ctx, "plantsService.GetPlantsByUserId")
+	defer span.End()
+
 	plantsResult := make([]Plant, 0)
 
 	plants, err := p.plantsStore.GetPlantsByUserId(ctx, userId)
@@ -69,13 +120,13 @@ func (p *PlantsService) GetPlantsByUserId(ctx context.Context, userId int64) ([]
 		}
 
 		// Set water event data if available
-		if latestWaterEvent != (database.Event{}) {
+		if latestWaterEvent.ID != 0 {
 			plantsResult[i].LatestWaterEvent = latestWaterEvent
 			plantsResult[i].NextWaterDue = calculateNextWaterTime(latestWaterEvent.Timestamp)
 		}
 
 		// Set fertilizer event data if available
-		if latestFertilizerEvent != (database.Event{}) {
+		if latestFertilizerEvent.ID != 0 {
 			plantsResult[i].LatestFertilizerEvent = latestFertilizerEvent
 			plantsResult[i].NextFertilizerDue = calculateNextFertilizerTime(latestFertilizerEvent.Timestamp)
 		}
@@ -84,20 +135,43 @@ func (p *PlantsService) GetPlantsByUserId(ctx context.Context, userId int64) ([]
 	return plantsResult, nil
 }
 
+// Care schedule in days between events of each type
+const (
+	WaterIntervalDays      = 7
+	FertilizerIntervalDays = 30
+)
+
 func calculateNextWaterTime(lastWaterTime time.Time) time.Time {
-	return lastWaterTime.AddDate(0, 0, 7)
+	return lastWaterTime.AddDate(0, 0, WaterIntervalDays)
 }
 
 func calculateNextFertilizerTime(lastFertilizerTime time.Time) time.Time {
-	return lastFertilizerTime.AddDate(0, 0, 30) // 30 days for fertilizer
+	return lastFertilizerTime.AddDate(0, 0, FertilizerIntervalDays)
 }
 
-func (p *PlantsService) GetPlantById(ctx context.Context, id int64) (Plant, error) {
+// getUserPlant loads a plant, treating one owned by another user as missing
+func (p *PlantsService) getUserPlant(ctx context.Context, userId int64, id int64) (database.Plant, error) {
 	plant, err := p.plantsStore.GetPlantById(ctx, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return Plant{}, nil
+		if errors.Is(err, domainErrors.NotFound) {
+			return database.Plant{}, ErrPlantNotFound
 		}
+		return database.Plant{}, err
+	}
+
+	if plant.Userid != userId {
+		return database.Plant{}, ErrPlantNotFound
+	}
+
+	return plant, nil
+}
+
+func (p *PlantsService) GetPlantById(ctx context.Context, userId int64, id int64) (Plant, error) {
+	ctx, span := tracing.Start(ctx, "plantsService.GetPlantById")
+	defer span.End()
+
+	plant, err := p.getUserPlant(ctx, userId, id)
+	if err != nil {
 		return Plant{}, err
 	}
 	model := DatabasePlantToPlantModel(plant)
@@ -106,13 +180,13 @@ func (p *PlantsService) GetPlantById(ctx context.Context, id int64) (Plant, erro
 	latestWaterEvent, latestFertilizerEvent, err := p.eventsStore.GetLatestWaterAndFertilizerEvents(ctx, model.Id)
 	if err == nil {
 		// Set water event data if available
-		if latestWaterEvent != (database.Event{}) {
+		if latestWaterEvent.ID != 0 {
 			model.LatestWaterEvent = latestWaterEvent
 			model.NextWaterDue = calculateNextWaterTime(latestWaterEvent.Timestamp)
 		}
 
 		// Set fertilizer event data if available
-		if latestFertilizerEvent != (database.Event{}) {
+		if latestFertilizerEvent.ID != 0 {
 			model.LatestFertilizerEvent = latestFertilizerEvent
 			model.NextFertilizerDue = calculateNextFertilizerTime(latestFertilizerEvent.Timestamp)
 		}
@@ -121,21 +195,126 @@ func (p *PlantsService) GetPlantById(ctx context.Context, id int64) (Plant, erro
 	return model, nil
 }
 
+// GetPlantsVersion returns how many plants the user has and when the most
+// recent of them or their events changed, which is enough to tell whether a
+// list of them is stale without loading it
+func (p *PlantsService) GetPlantsVersion(ctx context.Context, userId int64) (database.GetPlantsVersionByUserIdRow, error) {
+	ctx, span := tracing.Start(ctx, "plantsService.GetPlantsVersion")
+	defer span.End()
+
+	return p.plantsStore.GetPlantsVersionByUserId(ctx, userId)
+}
+
+// GetPlantVersion returns the plant's current version without loading its events
+func (p *PlantsService) GetPlantVersion(ctx context.Context, userId int64, id int64) (Version, error) {
+	ctx, span := tracing.Start(ctx, "plantsService.GetPlantVersion")
+	defer span.End()
+
+	plant, err := p.getUserPlant(ctx, userId, id)
+	if err != nil {
+		return Version{}, err
+	}
+	return Version{Number: plant.Version, Modified: plant.Updatedat}, nil
+}
+
 func (p *PlantsService) CreatePlant(ctx context.Context, name string, userId int64) (database.Plant, error) {
+	ctx, span := tracing.Start(ctx, "plantsService.CreatePlant")
+	defer span.End()
+
 	return p.plantsStore.CreatePlant(ctx, database.CreatePlantParams{
 		Name:   name,
 		Userid: userId,
 	})
 }
 
-func (p *PlantsService) UpdatePlant(ctx context.Context, id int64, name string) (Plant, error) {
-	_, err := p.plantsStore.UpdatePlant(ctx, database.UpdatePlantParams{
-		ID:   id,
-		Name: name,
+// UpdatePlant renames a plant if its version satisfies precondition
+func (p *PlantsService) UpdatePlant(ctx context.Context, userId int64, id int64, precondition Precondition, name string) (Plant, error) {
+	ctx, span := tracing.Start(ctx, "plantsService.UpdatePlant")
+	defer span.End()
+
+	plant, err := p.getUserPlant(ctx, userId, id)
+	if err != nil {
+		return Plant{}, err
+	}
+
+	if !precondition.Allows(plant.Version) {
+		return Plant{}, ErrPlantChanged
+	}
+
+	// The version is checked again by the update in case of a concurrent write
+	_, err = p.plantsStore.UpdatePlant(ctx, database.UpdatePlantParams{
+		ID:      id,
+		Name:    name,
+		Version: plant.Version,
 	})
 	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return Plant{}, ErrPlantChanged
+		}
 		return Plant{}, err
 	}
 	// Fetch the updated plant and its latest water event
-	return p.GetPlantById(ctx, id)
+	return p.GetPlantById(ctx, userId, id)
+}
+
+// PatchPlant changes the fields set in patch, all in one update, if the
+// plant's version satisfies precondition
+func (p *PlantsService) PatchPlant(ctx context.Context, userId int64, id int64, precondition Precondition, patch Patch) (Plant, error) {
+	ctx, span := tracing.Start(ctx, "plantsService.PatchPlant")
+	defer span.End()
+
+	plant, err := p.getUserPlant(ctx, userId, id)
+	if err != nil {
+		return Plant{}, err
+	}
+
+	if !precondition.Allows(plant.Version) {
+		return Plant{}, ErrPlantChanged
+	}
+
+	// Any update touches the plant, so don't make one that changes nothing
+	if patch.empty() {
+		return p.GetPlantById(ctx, userId, id)
+	}
+
+	params := database.PatchPlantParams{ID: id, Version: plant.Version}
+	if patch.Name != nil {
+		params.Name = pgtype.Text{String: *patch.Name, Valid: true}
+	}
+
+	// The version is checked again by the update in case of a concurrent write
+	if _, err := p.plantsStore.PatchPlant(ctx, params); err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return Plant{}, ErrPlantChanged
+		}
+		return Plant{}, err
+	}
+	return p.GetPlantById(ctx, userId, id)
+}
+
+// DeletePlant deletes a plant and its events if its version satisfies precondition
+func (p *PlantsService) DeletePlant(ctx context.Context, userId int64, id int64, precondition Precondition) error {
+	ctx, span := tracing.Start(ctx, "plantsService.DeletePlant")
+	defer span.End()
+
+	plant, err := p.getUserPlant(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+
+	if !precondition.Allows(plant.Version) {
+		return ErrPlantChanged
+	}
+
+	deleted, err := p.plantsStore.DeletePlant(ctx, database.DeletePlantParams{
+		ID:      id,
+		Version: plant.Version,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrPlantChanged
+	}
+	return nil
 }
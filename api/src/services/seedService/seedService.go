@@ -0,0 +1,292 @@
+package seedService
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/services/plantsService"
+	"github.com/ReidMason/plant-tracker/src/services/usersService"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	seedStore "github.com/ReidMason/plant-tracker/src/stores/seedStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+)
+
+var firstNames = []string{
+	"Ava", "Ben", "Chloe", "Dan", "Ella", "Finn", "Grace", "Harry", "Isla", "Jack",
+	"Kate", "Leo", "Mia", "Noah", "Olive", "Priya", "Quinn", "Rosa", "Sam", "Theo",
+}
+
+var plantNames = []string{
+	"Monstera", "Pothos", "Snake Plant", "Fiddle Leaf Fig", "ZZ Plant", "Peace Lily",
+	"Spider Plant", "Calathea", "Rubber Plant", "Aloe Vera", "Boston Fern", "String of Pearls",
+	"Hoya", "Philodendron", "Jade Plant", "Bird of Paradise", "Chinese Evergreen", "Dracaena",
+	"Orchid", "Cactus", "Basil", "Rosemary", "Bonsai", "Maidenhair Fern",
+}
+
+var rooms = []string{"Kitchen", "Bedroom", "Lounge", "Bathroom", "Office", "Hallway", "Balcony"}
+
+var notes = []string{
+	"Soil was bone dry", "Leaves looking droopy", "New leaf unfurling!", "Rotated towards the window",
+	"Bottom watered", "Used rain water", "Wiped the leaves", "Spotted some yellowing",
+	"Half strength feed", "Moved out of direct sun",
+}
+
+type Options struct {
+	Now           time.Time
+	Users         int
+	PlantsPerUser int
+	Days          int
+	BatchSize     int
+	Seed          uint64
+}
+
+type Summary struct {
+	Users  int64
+	Plants int64
+	Events int64
+}
+
+type SeedService struct {
+	seedStore seedStore.SeedStore
+}
+
+func New(seedStore seedStore.SeedStore) *SeedService {
+	return &SeedService{
+		seedStore: seedStore,
+	}
+}
+
+// carer describes how reliably a user looks after their plants
+type carer struct {
+	absences  [][2]time.Time
+	diligence float64
+}
+
+// Seed creates users with plants and a history of care events. The same seed
+// and options always produce the same data.
+func (s *SeedService) Seed(ctx context.Context, options Options) (Summary, error) {
+	ctx, span := tracing.Start(ctx, "seedService.Seed")
+	defer span.End()
+
+	rng := rand.New(rand.NewPCG(options.Seed, options.Seed>>32|1))
+	start := options.Now.AddDate(0, 0, -options.Days)
+	summary := Summary{}
+
+	users, err := s.createUsers(ctx, rng, options)
+	if err != nil {
+		return summary, err
+	}
+	summary.Users = int64(len(users))
+
+	events := newEventBatcher(ctx, s.seedStore, options.BatchSize)
+	for _, user := range users {
+		names := make([]string, options.PlantsPerUser)
+		for i := range names {
+			names[i] = fmt.Sprintf("%s (%s)", pick(rng, plantNames), pick(rng, rooms))
+		}
+
+		plants, err := s.seedStore.CreatePlants(ctx, database.CreatePlantsParams{
+			Names:  names,
+			UserID: user.ID,
+		})
+		if err != nil {
+			return summary, err
+		}
+		summary.Plants += int64(len(plants))
+
+		carer := newCarer(rng, start, options.Now)
+		// Everyone looks after their own plants
+		add := func(event database.CreateEventParams) error {
+			event.Actorids = []int64{user.ID}
+			return events.add(event)
+		}
+		for _, plant := range plants {
+			if err := carer.care(rng, plant.ID, start, options.Now, add); err != nil {
+				return summary, err
+			}
+		}
+	}
+
+	if err := events.flush(); err != nil {
+		return summary, err
+	}
+	summary.Events = events.created
+
+	return summary, nil
+}
+
+func (s *SeedService) createUsers(ctx context.Context, rng *rand.Rand, options Options) ([]database.User, error) {
+	existing, err := s.seedStore.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// User names are unique so number them past any that are already taken
+	taken := make(map[string]bool, len(existing))
+	for _, user := range existing {
+		taken[user.Name] = true
+	}
+
+	created := make([]database.User, 0, options.Users)
+	params := database.CreateUsersParams{}
+	for i := 0; i < options.Users; i++ {
+		first := pick(rng, firstNames)
+		name := first
+		for n := 2; taken[name]; n++ {
+			name = fmt.Sprintf("%s %d", first, n)
+		}
+		taken[name] = true
+
+		params.Names = append(params.Names, name)
+		params.Colours = append(params.Colours, pick(rng, usersService.Colours))
+		if len(params.Names) == options.BatchSize || i == options.Users-1 {
+			users, err := s.seedStore.CreateUsers(ctx, params)
+			if err != nil {
+				return nil, err
+			}
+			created = append(created, users...)
+			params = database.CreateUsersParams{}
+		}
+	}
+
+	return created, nil
+}
+
+func newCarer(rng *rand.Rand, start time.Time, end time.Time) carer {
+	c := carer{diligence: 0.55 + rng.Float64()*0.45}
+
+	// Most people go away once or twice and nothing gets watered
+	for i := rng.IntN(3); i > 0; i-- {
+		from := randomTime(rng, start, end)
+		c.absences = append(c.absences, [2]time.Time{from, from.AddDate(0, 0, 7+rng.IntN(15))})
+	}
+
+	return c
+}
+
+func (c carer) care(rng *rand.Rand, plantId int64, start time.Time, end time.Time, add func(database.CreateEventParams) error) error {
+	// Thirsty and drought tolerant plants drift either side of the schedule
+	waterDays := float64(plantsService.WaterIntervalDays) * (0.5 + rng.Float64())
+	if err := c.careFor(rng, plantId, 1, waterDays, start, end, add); err != nil {
+		return err
+	}
+
+	if rng.Float64() < 0.2 {
+		return nil // Never fertilized
+	}
+	fertilizerDays := float64(plantsService.FertilizerIntervalDays) * (0.8 + rng.Float64()*0.5)
+	return c.careFor(rng, plantId, 2, fertilizerDays, start, end, add)
+}
+
+func (c carer) careFor(rng *rand.Rand, plantId int64, eventType int32, intervalDays float64, start time.Time, end time.Time, add func(database.CreateEventParams) error) error {
+	due := start.Add(days(rng.Float64() * intervalDays))
+	for due.Before(end) {
+		if until, away := c.awayAt(due); away {
+			due = until.Add(days(rng.Float64() * 2))
+			continue
+		}
+
+		// Forgetting pushes care back by a few days
+		if rng.Float64() > c.diligence {
+			due = due.Add(days(1 + rng.Float64()*3))
+			continue
+		}
+
+		timestamp := atTimeOfDay(rng, due)
+		if timestamp.After(end) {
+			break
+		}
+
+		event := database.CreateEventParams{
+			Plantid:   plantId,
+			Eventtype: eventType,
+			Timestamp: timestamp,
+		}
+		if rng.Float64() < 0.15 {
+			event.Note = pick(rng, notes)
+		}
+		if err := add(event); err != nil {
+			return err
+		}
+
+		// Occasionally a housemate waters the same plant again shortly after
+		if rng.Float64() < 0.03 {
+			event.Note = ""
+			event.Timestamp = timestamp.Add(time.Duration(5+rng.IntN(120)) * time.Minute)
+			if err := add(event); err != nil {
+				return err
+			}
+		}
+
+		due = due.Add(days(intervalDays * (0.7 + rng.Float64()*0.6)))
+	}
+
+	return nil
+}
+
+func (c carer) awayAt(t time.Time) (time.Time, bool) {
+	for _, absence := range c.absences {
+		if !t.Before(absence[0]) && t.Before(absence[1]) {
+			return absence[1], true
+		}
+	}
+	return time.Time{}, false
+}
+
+// eventBatcher buffers events and inserts them with COPY once a batch is full
+type eventBatcher struct {
+	ctx       context.Context
+	seedStore seedStore.SeedStore
+	batch     []database.CreateEventsParams
+	created   int64
+}
+
+func newEventBatcher(ctx context.Context, seedStore seedStore.SeedStore, size int) *eventBatcher {
+	return &eventBatcher{
+		ctx:       ctx,
+		seedStore: seedStore,
+		batch:     make([]database.CreateEventsParams, 0, size),
+	}
+}
+
+func (b *eventBatcher) add(event database.CreateEventParams) error {
+	b.batch = append(b.batch, database.CreateEventsParams(event))
+	if len(b.batch) < cap(b.batch) {
+		return nil
+	}
+	return b.flush()
+}
+
+func (b *eventBatcher) flush() error {
+	if len(b.batch) == 0 {
+		return nil
+	}
+
+	created, err := b.seedStore.CreateEvents(b.ctx, b.batch)
+	if err != nil {
+		return err
+	}
+	b.created += created
+	b.batch = b.batch[:0]
+	return nil
+}
+
+func pick[T any](rng *rand.Rand, values []T) T {
+	return values[rng.IntN(len(values))]
+}
+
+func days(n float64) time.Duration {
+	return time.Duration(n * float64(24*time.Hour))
+}
+
+func randomTime(rng *rand.Rand, start time.Time, end time.Time) time.Time {
+	return start.Add(time.Duration(rng.Int64N(int64(end.Sub(start)))))
+}
+
+// atTimeOfDay moves t to a waking hour on the same day
+func atTimeOfDay(rng *rand.Rand, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.Add(time.Duration(7*60+rng.IntN(15*60)) * time.Minute)
+}
@@ -0,0 +1,201 @@
+package statsService
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/services/plantsService"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	plantsStore "github.com/ReidMason/plant-tracker/src/stores/plantsStore"
+	statsStore "github.com/ReidMason/plant-tracker/src/stores/statsStore"
+	usersStore "github.com/ReidMason/plant-tracker/src/stores/usersStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
+)
+
+type StatsService interface {
+	GetUserStats(ctx context.Context, userId int64) (Stats, error)
+	GetPlantStats(ctx context.Context, userId int64, plantId int64) (Stats, error)
+}
+
+// Stats describes how consistently a user or plant has been cared for
+type Stats struct {
+	Water     IntervalStats
+	Fertilize IntervalStats
+	Weekly    []Bucket
+	Monthly   []Bucket
+}
+
+// IntervalStats summarises the gaps between consecutive events of one type
+type IntervalStats struct {
+	EventCount       int64
+	IntervalCount    int64
+	MeanDays         float64
+	MedianDays       float64
+	LongestGapDays   float64
+	OnTimePercentage float64
+	ScheduleDays     int
+}
+
+// Bucket holds the number of events of each type within a week or month
+type Bucket struct {
+	Start          time.Time
+	WaterCount     int64
+	FertilizeCount int64
+}
+
+type statsService struct {
+	statsStore  statsStore.StatsStore
+	usersStore  usersStore.UsersStore
+	plantsStore plantsStore.PlantsStore
+}
+
+func New(statsStore statsStore.StatsStore, usersStore usersStore.UsersStore, plantsStore plantsStore.PlantsStore) *statsService {
+	return &statsService{
+		statsStore:  statsStore,
+		usersStore:  usersStore,
+		plantsStore: plantsStore,
+	}
+}
+
+func (s *statsService) GetUserStats(ctx context.Context, userId int64) (Stats, error) {
+	ctx, span := tracing.Start(ctx, "statsService.GetUserStats")
+	defer span.End()
+
+	if _, err := s.usersStore.GetUserById(ctx, userId); err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return Stats{}, ErrUserNotFound
+		}
+		return Stats{}, err
+	}
+
+	intervals, err := s.statsStore.GetIntervalStatsByUserId(ctx, database.GetIntervalStatsByUserIdParams{
+		UserID:                 userId,
+		WaterIntervalDays:      plantsService.WaterIntervalDays,
+		FertilizerIntervalDays: plantsService.FertilizerIntervalDays,
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := newStats()
+	for _, row := range intervals {
+		stats.setIntervals(row.Eventtype, database.GetIntervalStatsByPlantIdRow(row))
+	}
+
+	for bucket, target := range map[string]*[]Bucket{"week": &stats.Weekly, "month": &stats.Monthly} {
+		counts, err := s.statsStore.GetEventCountsByUserId(ctx, database.GetEventCountsByUserIdParams{
+			UserID: userId,
+			Bucket: bucket,
+		})
+		if err != nil {
+			return Stats{}, err
+		}
+
+		for _, row := range counts {
+			*target = addToBuckets(*target, row.Bucket, row.Eventtype, row.EventCount)
+		}
+	}
+
+	return stats, nil
+}
+
+func (s *statsService) GetPlantStats(ctx context.Context, userId int64, plantId int64) (Stats, error) {
+	ctx, span := tracing.Start(ctx, "statsService.GetPlantStats")
+	defer span.End()
+
+	plant, err := s.plantsStore.GetPlantById(ctx, plantId)
+	if err != nil {
+		if errors.Is(err, domainErrors.NotFound) {
+			return Stats{}, ErrPlantNotFound
+		}
+		return Stats{}, err
+	}
+
+	if plant.Userid != userId {
+		return Stats{}, ErrPlantNotFound
+	}
+
+	intervals, err := s.statsStore.GetIntervalStatsByPlantId(ctx, database.GetIntervalStatsByPlantIdParams{
+		PlantID:                plantId,
+		WaterIntervalDays:      plantsService.WaterIntervalDays,
+		FertilizerIntervalDays: plantsService.FertilizerIntervalDays,
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := newStats()
+	for _, row := range intervals {
+		stats.setIntervals(row.Eventtype, row)
+	}
+
+	for bucket, target := range map[string]*[]Bucket{"week": &stats.Weekly, "month": &stats.Monthly} {
+		counts, err := s.statsStore.GetEventCountsByPlantId(ctx, database.GetEventCountsByPlantIdParams{
+			PlantID: plantId,
+			Bucket:  bucket,
+		})
+		if err != nil {
+			return Stats{}, err
+		}
+
+		for _, row := range counts {
+			*target = addToBuckets(*target, row.Bucket, row.Eventtype, row.EventCount)
+		}
+	}
+
+	return stats, nil
+}
+
+func newStats() Stats {
+	return Stats{
+		Water:     IntervalStats{ScheduleDays: plantsService.WaterIntervalDays},
+		Fertilize: IntervalStats{ScheduleDays: plantsService.FertilizerIntervalDays},
+		Weekly:    make([]Bucket, 0),
+		Monthly:   make([]Bucket, 0),
+	}
+}
+
+func (s *Stats) setIntervals(eventType int32, row database.GetIntervalStatsByPlantIdRow) {
+	var target *IntervalStats
+	switch eventType {
+	case 1: // Water event
+		target = &s.Water
+	case 2: // Fertilizer event
+		target = &s.Fertilize
+	default:
+		return
+	}
+
+	target.EventCount = row.EventCount
+	target.IntervalCount = row.IntervalCount
+	target.MeanDays = row.MeanDays
+	target.MedianDays = row.MedianDays
+	target.LongestGapDays = row.LongestGapDays
+	if row.IntervalCount > 0 {
+		target.OnTimePercentage = float64(row.OnTimeCount) / float64(row.IntervalCount) * 100
+	}
+}
+
+// addToBuckets relies on the rows being ordered by bucket start
+func addToBuckets(buckets []Bucket, start time.Time, eventType int32, count int64) []Bucket {
+	if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+		buckets = append(buckets, Bucket{Start: start})
+	}
+
+	bucket := &buckets[len(buckets)-1]
+	switch eventType {
+	case 1: // Water event
+		bucket.WaterCount = count
+	case 2: // Fertilizer event
+		bucket.FertilizeCount = count
+	}
+
+	return buckets
+}
+
+var (
+	ErrUserNotFound  = domainErrors.New(domainErrors.NotFound, "User not found")
+	ErrPlantNotFound = domainErrors.New(domainErrors.NotFound, "Plant not found")
+)
@@ -2,15 +2,18 @@ package usersService
 
 import (
 	"context"
+	"errors"
 	"math/rand"
 	"time"
 
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
 	"github.com/ReidMason/plant-tracker/src/stores/database"
 	usersStore "github.com/ReidMason/plant-tracker/src/stores/usersStore"
+	"github.com/ReidMason/plant-tracker/src/tracing"
 )
 
 // Available colours for users
-var colours = []string{
+var Colours = []string{
 	"#F44336", // Red
 	"#E91E63", // Pink
 	"#9C27B0", // Purple
@@ -29,10 +32,10 @@ var colours = []string{
 	"#FF5722", // Deep Orange
 }
 
-// GetRandomColour returns a random colour from the colours slice
+// GetRandomColour returns a random colour from the Colours slice
 func GetRandomColour() string {
 	rand.Seed(time.Now().UnixNano())
-	return colours[rand.Intn(len(colours))]
+	return Colours[rand.Intn(len(Colours))]
 }
 
 type GetUsersService interface {
@@ -41,6 +44,9 @@ type GetUsersService interface {
 	CreateUser(ctx context.Context, name string) (database.User, error)
 }
 
+// ErrUserNotFound is returned when looking up a user that doesn't exist
+var ErrUserNotFound = domainErrors.New(domainErrors.NotFound, "User not found")
+
 type UsersService struct {
 	usersStore usersStore.UsersStore
 }
@@ -52,14 +58,27 @@ func New(usersStore usersStore.UsersStore) *UsersService {
 }
 
 func (u *UsersService) GetUsers(ctx context.Context) ([]database.User, error) {
+	ctx, span := tracing.Start(ctx, "usersService.GetUsers")
+	defer span.End()
+
 	return u.usersStore.GetUsers(ctx)
 }
 
 func (u *UsersService) GetUserById(ctx context.Context, id int64) (database.User, error) {
-	return u.usersStore.GetUserById(ctx, id)
+	ctx, span := tracing.Start(ctx, "usersService.GetUserById")
+	defer span.End()
+
+	user, err := u.usersStore.GetUserById(ctx, id)
+	if errors.Is(err, domainErrors.NotFound) {
+		return database.User{}, ErrUserNotFound
+	}
+	return user, err
 }
 
 func (u *UsersService) CreateUser(ctx context.Context, name string) (database.User, error) {
+	ctx, span := tracing.Start(ctx, "usersService.CreateUser")
+	defer span.End()
+
 	return u.usersStore.CreateUser(ctx, database.CreateUserParams{
 		Name:   name,
 		Colour: GetRandomColour(),
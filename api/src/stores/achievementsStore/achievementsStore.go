@@ -0,0 +1,14 @@
+package achievementsStore
+
+import (
+	"context"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+)
+
+type AchievementsStore interface {
+	GetAchievementsByUserId(ctx context.Context, userid int64) ([]database.Achievement, error)
+	CreateAchievement(ctx context.Context, arg database.CreateAchievementParams) (int64, error)
+	GetCareProgressByUserId(ctx context.Context, userid int64) (database.GetCareProgressByUserIdRow, error)
+	GetCareStreakByUserId(ctx context.Context, arg database.GetCareStreakByUserIdParams) (database.GetCareStreakByUserIdRow, error)
+}
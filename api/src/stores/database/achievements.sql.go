@@ -0,0 +1,133 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: achievements.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const createAchievement = `-- name: CreateAchievement :execrows
+INSERT INTO achievements (userId, code, earnedAt)
+VALUES ($1, $2, $3)
+ON CONFLICT (userId, code) DO NOTHING
+`
+
+type CreateAchievementParams struct {
+	Userid   int64
+	Code     string
+	Earnedat time.Time
+}
+
+func (q *Queries) CreateAchievement(ctx context.Context, arg CreateAchievementParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createAchievement, arg.Userid, arg.Code, arg.Earnedat)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getAchievementsByUserId = `-- name: GetAchievementsByUserId :many
+SELECT userid, code, earnedat FROM achievements WHERE userId = $1 ORDER BY earnedAt
+`
+
+func (q *Queries) GetAchievementsByUserId(ctx context.Context, userid int64) ([]Achievement, error) {
+	rows, err := q.db.Query(ctx, getAchievementsByUserId, userid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Achievement
+	for rows.Next() {
+		var i Achievement
+		if err := rows.Scan(&i.Userid, &i.Code, &i.Earnedat); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCareProgressByUserId = `-- name: GetCareProgressByUserId :one
+SELECT COUNT(*) FILTER (WHERE e.eventtype = 1)::bigint AS water_count,
+       COUNT(*) FILTER (WHERE e.eventtype = 2)::bigint AS fertilize_count,
+       COUNT(DISTINCT e.plantid)::bigint AS plants_cared_for,
+       COUNT(DISTINCT e.plantid) FILTER (WHERE e.eventtype = 1)::bigint AS plants_watered
+FROM events e
+WHERE e.actorIds @> ARRAY[$1::bigint]
+`
+
+type GetCareProgressByUserIdRow struct {
+	WaterCount     int64
+	FertilizeCount int64
+	PlantsCaredFor int64
+	PlantsWatered  int64
+}
+
+// Care is credited to whoever did it, including for a housemate's plants
+func (q *Queries) GetCareProgressByUserId(ctx context.Context, userID int64) (GetCareProgressByUserIdRow, error) {
+	row := q.db.QueryRow(ctx, getCareProgressByUserId, userID)
+	var i GetCareProgressByUserIdRow
+	err := row.Scan(
+		&i.WaterCount,
+		&i.FertilizeCount,
+		&i.PlantsCaredFor,
+		&i.PlantsWatered,
+	)
+	return i, err
+}
+
+const getCareStreakByUserId = `-- name: GetCareStreakByUserId :one
+WITH gaps AS (
+  SELECT e.plantid,
+         e.timestamp,
+         e.timestamp - LAG(e.timestamp) OVER (PARTITION BY e.plantid ORDER BY e.timestamp) AS gap
+  FROM events e
+  JOIN plants p ON p.id = e.plantid
+  WHERE p.userid = $3 AND e.eventtype = $4
+),
+latest AS (
+  SELECT plantid, MAX(timestamp) AS timestamp
+  FROM gaps
+  GROUP BY plantid
+)
+SELECT COALESCE(
+         (SELECT MAX(timestamp) FROM gaps WHERE gap > make_interval(days => $1::int)),
+         (SELECT MIN(timestamp) FROM gaps),
+         $2::timestamptz
+       )::timestamptz AS streak_start,
+       EXISTS (
+         SELECT 1 FROM latest
+         WHERE latest.timestamp + make_interval(days => $1::int) < $2::timestamptz
+       ) AS overdue
+`
+
+type GetCareStreakByUserIdParams struct {
+	IntervalDays int32
+	Now          time.Time
+	UserID       int64
+	EventType    int32
+}
+
+type GetCareStreakByUserIdRow struct {
+	StreakStart time.Time
+	Overdue     bool
+}
+
+func (q *Queries) GetCareStreakByUserId(ctx context.Context, arg GetCareStreakByUserIdParams) (GetCareStreakByUserIdRow, error) {
+	row := q.db.QueryRow(ctx, getCareStreakByUserId,
+		arg.IntervalDays,
+		arg.Now,
+		arg.UserID,
+		arg.EventType,
+	)
+	var i GetCareStreakByUserIdRow
+	err := row.Scan(&i.StreakStart, &i.Overdue)
+	return i, err
+}
@@ -0,0 +1,112 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: backup.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const backfillEventActors = `-- name: BackfillEventActors :exec
+UPDATE events e SET actorIds = ARRAY[p.userId]
+FROM plants p
+WHERE p.id = e.plantId AND e.actorIds = '{}'
+`
+
+// Archives from before events had actors restore them with none, so credit
+// the plant's owner as the migration did
+func (q *Queries) BackfillEventActors(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, backfillEventActors)
+	return err
+}
+
+const countUserData = `-- name: CountUserData :one
+SELECT (SELECT COUNT(*) FROM users)::bigint AS users,
+       (SELECT COUNT(*) FROM plants)::bigint AS plants,
+       (SELECT COUNT(*) FROM events)::bigint AS events
+`
+
+type CountUserDataRow struct {
+	Users  int64
+	Plants int64
+	Events int64
+}
+
+func (q *Queries) CountUserData(ctx context.Context) (CountUserDataRow, error) {
+	row := q.db.QueryRow(ctx, countUserData)
+	var i CountUserDataRow
+	err := row.Scan(&i.Users, &i.Plants, &i.Events)
+	return i, err
+}
+
+const resetEventsSequence = `-- name: ResetEventsSequence :exec
+SELECT setval(pg_get_serial_sequence('events', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM events
+`
+
+func (q *Queries) ResetEventsSequence(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, resetEventsSequence)
+	return err
+}
+
+const resetPlantsSequence = `-- name: ResetPlantsSequence :exec
+SELECT setval(pg_get_serial_sequence('plants', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM plants
+`
+
+func (q *Queries) ResetPlantsSequence(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, resetPlantsSequence)
+	return err
+}
+
+const resetUsersSequence = `-- name: ResetUsersSequence :exec
+SELECT setval(pg_get_serial_sequence('users', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM users
+`
+
+func (q *Queries) ResetUsersSequence(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, resetUsersSequence)
+	return err
+}
+
+type RestoreAchievementsParams struct {
+	Userid   int64
+	Code     string
+	Earnedat time.Time
+}
+
+const restoreEventType = `-- name: RestoreEventType :exec
+INSERT INTO eventTypes (id, name) VALUES ($1, $2)
+ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name
+`
+
+type RestoreEventTypeParams struct {
+	ID   int32
+	Name string
+}
+
+func (q *Queries) RestoreEventType(ctx context.Context, arg RestoreEventTypeParams) error {
+	_, err := q.db.Exec(ctx, restoreEventType, arg.ID, arg.Name)
+	return err
+}
+
+type RestoreEventsParams struct {
+	ID        int64
+	Plantid   int64
+	Eventtype int32
+	Note      string
+	Timestamp time.Time
+	Actorids  []int64
+}
+
+type RestorePlantsParams struct {
+	ID     int64
+	Name   string
+	Userid int64
+}
+
+type RestoreUsersParams struct {
+	ID     int64
+	Name   string
+	Colour string
+}
@@ -0,0 +1,185 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: copyfrom.go
+
+package database
+
+import (
+	"context"
+)
+
+// iteratorForCreateEvents implements pgx.CopyFromSource.
+type iteratorForCreateEvents struct {
+	rows                 []CreateEventsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCreateEvents) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCreateEvents) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].Plantid,
+		r.rows[0].Eventtype,
+		r.rows[0].Note,
+		r.rows[0].Timestamp,
+		r.rows[0].Actorids,
+	}, nil
+}
+
+func (r iteratorForCreateEvents) Err() error {
+	return nil
+}
+
+func (q *Queries) CreateEvents(ctx context.Context, arg []CreateEventsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"events"}, []string{"plantid", "eventtype", "note", "timestamp", "actorids"}, &iteratorForCreateEvents{rows: arg})
+}
+
+// iteratorForRestoreAchievements implements pgx.CopyFromSource.
+type iteratorForRestoreAchievements struct {
+	rows                 []RestoreAchievementsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForRestoreAchievements) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForRestoreAchievements) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].Userid,
+		r.rows[0].Code,
+		r.rows[0].Earnedat,
+	}, nil
+}
+
+func (r iteratorForRestoreAchievements) Err() error {
+	return nil
+}
+
+func (q *Queries) RestoreAchievements(ctx context.Context, arg []RestoreAchievementsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"achievements"}, []string{"userid", "code", "earnedat"}, &iteratorForRestoreAchievements{rows: arg})
+}
+
+// iteratorForRestoreEvents implements pgx.CopyFromSource.
+type iteratorForRestoreEvents struct {
+	rows                 []RestoreEventsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForRestoreEvents) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForRestoreEvents) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ID,
+		r.rows[0].Plantid,
+		r.rows[0].Eventtype,
+		r.rows[0].Note,
+		r.rows[0].Timestamp,
+		r.rows[0].Actorids,
+	}, nil
+}
+
+func (r iteratorForRestoreEvents) Err() error {
+	return nil
+}
+
+func (q *Queries) RestoreEvents(ctx context.Context, arg []RestoreEventsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"events"}, []string{"id", "plantid", "eventtype", "note", "timestamp", "actorids"}, &iteratorForRestoreEvents{rows: arg})
+}
+
+// iteratorForRestorePlants implements pgx.CopyFromSource.
+type iteratorForRestorePlants struct {
+	rows                 []RestorePlantsParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForRestorePlants) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForRestorePlants) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ID,
+		r.rows[0].Name,
+		r.rows[0].Userid,
+	}, nil
+}
+
+func (r iteratorForRestorePlants) Err() error {
+	return nil
+}
+
+func (q *Queries) RestorePlants(ctx context.Context, arg []RestorePlantsParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"plants"}, []string{"id", "name", "userid"}, &iteratorForRestorePlants{rows: arg})
+}
+
+// iteratorForRestoreUsers implements pgx.CopyFromSource.
+type iteratorForRestoreUsers struct {
+	rows                 []RestoreUsersParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForRestoreUsers) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForRestoreUsers) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ID,
+		r.rows[0].Name,
+		r.rows[0].Colour,
+	}, nil
+}
+
+func (r iteratorForRestoreUsers) Err() error {
+	return nil
+}
+
+func (q *Queries) RestoreUsers(ctx context.Context, arg []RestoreUsersParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"users"}, []string{"id", "name", "colour"}, &iteratorForRestoreUsers{rows: arg})
+}
@@ -11,9 +11,9 @@ import (
 )
 
 const createEvent = `-- name: CreateEvent :one
-INSERT INTO events (plantId, eventType, note, timestamp)
-VALUES ($1, $2, $3, $4)
-RETURNING id, plantid, eventtype, note, timestamp
+INSERT INTO events (plantId, eventType, note, timestamp, actorIds)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, plantid, eventtype, note, timestamp, actorids
 `
 
 type CreateEventParams struct {
@@ -21,6 +21,7 @@ type CreateEventParams struct {
 	Eventtype int32
 	Note      string
 	Timestamp time.Time
+	Actorids  []int64
 }
 
 func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
@@ -29,6 +30,7 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		arg.Eventtype,
 		arg.Note,
 		arg.Timestamp,
+		arg.Actorids,
 	)
 	var i Event
 	err := row.Scan(
@@ -37,12 +39,21 @@ func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event
 		&i.Eventtype,
 		&i.Note,
 		&i.Timestamp,
+		&i.Actorids,
 	)
 	return i, err
 }
 
+type CreateEventsParams struct {
+	Plantid   int64
+	Eventtype int32
+	Note      string
+	Timestamp time.Time
+	Actorids  []int64
+}
+
 const getEventById = `-- name: GetEventById :one
-SELECT id, plantid, eventtype, note, timestamp FROM events WHERE id = $1
+SELECT id, plantid, eventtype, note, timestamp, actorids FROM events WHERE id = $1
 `
 
 func (q *Queries) GetEventById(ctx context.Context, id int64) (Event, error) {
@@ -54,12 +65,13 @@ func (q *Queries) GetEventById(ctx context.Context, id int64) (Event, error) {
 		&i.Eventtype,
 		&i.Note,
 		&i.Timestamp,
+		&i.Actorids,
 	)
 	return i, err
 }
 
 const getEventsByPlantId = `-- name: GetEventsByPlantId :many
-SELECT id, plantid, eventtype, note, timestamp FROM events WHERE plantId = $1
+SELECT id, plantid, eventtype, note, timestamp, actorids FROM events WHERE plantId = $1
 `
 
 func (q *Queries) GetEventsByPlantId(ctx context.Context, plantid int64) ([]Event, error) {
@@ -77,6 +89,7 @@ func (q *Queries) GetEventsByPlantId(ctx context.Context, plantid int64) ([]Even
 			&i.Eventtype,
 			&i.Note,
 			&i.Timestamp,
+			&i.Actorids,
 		); err != nil {
 			return nil, err
 		}
@@ -89,7 +102,7 @@ func (q *Queries) GetEventsByPlantId(ctx context.Context, plantid int64) ([]Even
 }
 
 const getLatestEventsByTypeForPlant = `-- name: GetLatestEventsByTypeForPlant :many
-SELECT DISTINCT ON (eventtype) id, plantid, eventtype, note, timestamp
+SELECT DISTINCT ON (eventtype) id, plantid, eventtype, note, timestamp, actorids
 FROM events 
 WHERE plantid = $1 AND eventtype IN (1, 2)
 ORDER BY eventtype, timestamp DESC
@@ -110,6 +123,7 @@ func (q *Queries) GetLatestEventsByTypeForPlant(ctx context.Context, plantid int
 			&i.Eventtype,
 			&i.Note,
 			&i.Timestamp,
+			&i.Actorids,
 		); err != nil {
 			return nil, err
 		}
@@ -120,3 +134,67 @@ func (q *Queries) GetLatestEventsByTypeForPlant(ctx context.Context, plantid int
 	}
 	return items, nil
 }
+
+const getRecentEvent = `-- name: GetRecentEvent :one
+SELECT id, plantid, eventtype, note, timestamp, actorids FROM events
+WHERE plantId = $1 AND eventType = $2 AND timestamp >= $3
+ORDER BY timestamp DESC
+LIMIT 1
+`
+
+type GetRecentEventParams struct {
+	Plantid   int64
+	Eventtype int32
+	Since     time.Time
+}
+
+func (q *Queries) GetRecentEvent(ctx context.Context, arg GetRecentEventParams) (Event, error) {
+	row := q.db.QueryRow(ctx, getRecentEvent, arg.Plantid, arg.Eventtype, arg.Since)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Plantid,
+		&i.Eventtype,
+		&i.Note,
+		&i.Timestamp,
+		&i.Actorids,
+	)
+	return i, err
+}
+
+const mergeIntoEvent = `-- name: MergeIntoEvent :one
+UPDATE events
+SET note = CASE
+      WHEN $1::text = '' THEN note
+      WHEN note = '' THEN $1::text
+      ELSE note || E'\n' || $1::text
+    END,
+    actorIds = CASE
+      WHEN $2::bigint = ANY(actorIds) THEN actorIds
+      ELSE array_append(actorIds, $2::bigint)
+    END
+WHERE id = $3
+RETURNING id, plantid, eventtype, note, timestamp, actorids
+`
+
+type MergeIntoEventParams struct {
+	Note    string
+	ActorID int64
+	ID      int64
+}
+
+// Adds a duplicate's note and actor to an event, leaving out an empty note
+// and an actor who is already listed
+func (q *Queries) MergeIntoEvent(ctx context.Context, arg MergeIntoEventParams) (Event, error) {
+	row := q.db.QueryRow(ctx, mergeIntoEvent, arg.Note, arg.ActorID, arg.ID)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.Plantid,
+		&i.Eventtype,
+		&i.Note,
+		&i.Timestamp,
+		&i.Actorids,
+	)
+	return i, err
+}
@@ -0,0 +1,134 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: idempotencyKeys.sql
+
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimIdempotencyKey = `-- name: ClaimIdempotencyKey :one
+INSERT INTO idempotency_keys (client, key, requestHash)
+VALUES ($1, $2, $3)
+ON CONFLICT (client, key) DO UPDATE
+SET requestHash = EXCLUDED.requestHash,
+    status = NULL,
+    headers = NULL,
+    body = NULL,
+    createdAt = now()
+WHERE idempotency_keys.createdAt < $4::timestamptz
+   OR (idempotency_keys.status IS NULL AND idempotency_keys.createdAt < $5::timestamptz)
+RETURNING client, key, requesthash, status, headers, body, createdat
+`
+
+type ClaimIdempotencyKeyParams struct {
+	Client          string
+	Key             string
+	RequestHash     []byte
+	ExpiredBefore   time.Time
+	AbandonedBefore time.Time
+}
+
+// Claims a key for a new request. A key that has expired, or whose request
+// was abandoned without completing, is taken over. Returns no row if the key
+// is held by another request.
+func (q *Queries) ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, claimIdempotencyKey,
+		arg.Client,
+		arg.Key,
+		arg.RequestHash,
+		arg.ExpiredBefore,
+		arg.AbandonedBefore,
+	)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Client,
+		&i.Key,
+		&i.Requesthash,
+		&i.Status,
+		&i.Headers,
+		&i.Body,
+		&i.Createdat,
+	)
+	return i, err
+}
+
+const completeIdempotencyKey = `-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status = $3, headers = $4, body = $5
+WHERE client = $1 AND key = $2
+`
+
+type CompleteIdempotencyKeyParams struct {
+	Client  string
+	Key     string
+	Status  pgtype.Int4
+	Headers []byte
+	Body    []byte
+}
+
+func (q *Queries) CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, completeIdempotencyKey,
+		arg.Client,
+		arg.Key,
+		arg.Status,
+		arg.Headers,
+		arg.Body,
+	)
+	return err
+}
+
+const deleteExpiredIdempotencyKeys = `-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys WHERE createdAt < $1
+`
+
+func (q *Queries) DeleteExpiredIdempotencyKeys(ctx context.Context, createdat time.Time) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteExpiredIdempotencyKeys, createdat)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT client, key, requesthash, status, headers, body, createdat FROM idempotency_keys WHERE client = $1 AND key = $2
+`
+
+type GetIdempotencyKeyParams struct {
+	Client string
+	Key    string
+}
+
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, arg.Client, arg.Key)
+	var i IdempotencyKey
+	err := row.Scan(
+		&i.Client,
+		&i.Key,
+		&i.Requesthash,
+		&i.Status,
+		&i.Headers,
+		&i.Body,
+		&i.Createdat,
+	)
+	return i, err
+}
+
+const releaseIdempotencyKey = `-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE client = $1 AND key = $2
+`
+
+type ReleaseIdempotencyKeyParams struct {
+	Client string
+	Key    string
+}
+
+func (q *Queries) ReleaseIdempotencyKey(ctx context.Context, arg ReleaseIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, releaseIdempotencyKey, arg.Client, arg.Key)
+	return err
+}
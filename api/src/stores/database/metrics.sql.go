@@ -0,0 +1,75 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: metrics.sql
+
+package database
+
+import (
+	"context"
+)
+
+const getPlantCareSnapshot = `-- name: GetPlantCareSnapshot :many
+WITH latest AS (
+  SELECT p.id,
+         p.name,
+         p.userid,
+         MAX(e.timestamp) FILTER (WHERE e.eventtype = 1) AS last_watered,
+         MAX(e.timestamp) FILTER (WHERE e.eventtype = 2) AS last_fertilized
+  FROM plants p
+  LEFT JOIN events e ON e.plantid = p.id
+  GROUP BY p.id
+)
+SELECT id,
+       name,
+       userid,
+       (last_watered IS NOT NULL)::bool AS watered,
+       COALESCE(EXTRACT(EPOCH FROM now() - last_watered) / 3600, 0)::float8 AS hours_since_watered,
+       COALESCE(last_watered + make_interval(days => $1::int) < now(), false)::bool AS water_overdue,
+       COALESCE(last_fertilized + make_interval(days => $2::int) < now(), false)::bool AS fertilizer_overdue
+FROM latest
+ORDER BY id
+`
+
+type GetPlantCareSnapshotParams struct {
+	WaterIntervalDays      int32
+	FertilizerIntervalDays int32
+}
+
+type GetPlantCareSnapshotRow struct {
+	ID                int64
+	Name              string
+	Userid            int64
+	Watered           bool
+	HoursSinceWatered float64
+	WaterOverdue      bool
+	FertilizerOverdue bool
+}
+
+func (q *Queries) GetPlantCareSnapshot(ctx context.Context, arg GetPlantCareSnapshotParams) ([]GetPlantCareSnapshotRow, error) {
+	rows, err := q.db.Query(ctx, getPlantCareSnapshot, arg.WaterIntervalDays, arg.FertilizerIntervalDays)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPlantCareSnapshotRow
+	for rows.Next() {
+		var i GetPlantCareSnapshotRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Userid,
+			&i.Watered,
+			&i.HoursSinceWatered,
+			&i.WaterOverdue,
+			&i.FertilizerOverdue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
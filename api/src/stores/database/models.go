@@ -6,14 +6,23 @@ package database
 
 import (
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Achievement struct {
+	Userid   int64
+	Code     string
+	Earnedat time.Time
+}
+
 type Event struct {
 	ID        int64
 	Plantid   int64
 	Eventtype int32
 	Note      string
 	Timestamp time.Time
+	Actorids  []int64
 }
 
 type Eventtype struct {
@@ -21,10 +30,22 @@ type Eventtype struct {
 	Name string
 }
 
+type IdempotencyKey struct {
+	Client      string
+	Key         string
+	Requesthash []byte
+	Status      pgtype.Int4
+	Headers     []byte
+	Body        []byte
+	Createdat   time.Time
+}
+
 type Plant struct {
-	ID     int64
-	Name   string
-	Userid int64
+	ID        int64
+	Name      string
+	Userid    int64
+	Updatedat time.Time
+	Version   int64
 }
 
 type User struct {
@@ -7,11 +7,14 @@ package database
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createPlant = `-- name: CreatePlant :one
 INSERT INTO plants (name, userId) VALUES ($1, $2)
-RETURNING id, name, userid
+RETURNING id, name, userid, updatedat, version
 `
 
 type CreatePlantParams struct {
@@ -22,23 +25,90 @@ type CreatePlantParams struct {
 func (q *Queries) CreatePlant(ctx context.Context, arg CreatePlantParams) (Plant, error) {
 	row := q.db.QueryRow(ctx, createPlant, arg.Name, arg.Userid)
 	var i Plant
-	err := row.Scan(&i.ID, &i.Name, &i.Userid)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Userid,
+		&i.Updatedat,
+		&i.Version,
+	)
 	return i, err
 }
 
+const createPlants = `-- name: CreatePlants :many
+INSERT INTO plants (name, userId)
+SELECT unnest($1::text[]), $2
+RETURNING id, name, userid, updatedat, version
+`
+
+type CreatePlantsParams struct {
+	Names  []string
+	UserID int64
+}
+
+func (q *Queries) CreatePlants(ctx context.Context, arg CreatePlantsParams) ([]Plant, error) {
+	rows, err := q.db.Query(ctx, createPlants, arg.Names, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Plant
+	for rows.Next() {
+		var i Plant
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Userid,
+			&i.Updatedat,
+			&i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deletePlant = `-- name: DeletePlant :execrows
+DELETE FROM plants
+WHERE id = $1 AND version = $2
+`
+
+type DeletePlantParams struct {
+	ID      int64
+	Version int64
+}
+
+func (q *Queries) DeletePlant(ctx context.Context, arg DeletePlantParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePlant, arg.ID, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getPlantById = `-- name: GetPlantById :one
-SELECT id, name, userid FROM plants WHERE id = $1
+SELECT id, name, userid, updatedat, version FROM plants WHERE id = $1
 `
 
 func (q *Queries) GetPlantById(ctx context.Context, id int64) (Plant, error) {
 	row := q.db.QueryRow(ctx, getPlantById, id)
 	var i Plant
-	err := row.Scan(&i.ID, &i.Name, &i.Userid)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Userid,
+		&i.Updatedat,
+		&i.Version,
+	)
 	return i, err
 }
 
 const getPlantsByUserId = `-- name: GetPlantsByUserId :many
-SELECT id, name, userid FROM plants WHERE userId = $1
+SELECT id, name, userid, updatedat, version FROM plants WHERE userId = $1
 `
 
 func (q *Queries) GetPlantsByUserId(ctx context.Context, userid int64) ([]Plant, error) {
@@ -50,7 +120,13 @@ func (q *Queries) GetPlantsByUserId(ctx context.Context, userid int64) ([]Plant,
 	var items []Plant
 	for rows.Next() {
 		var i Plant
-		if err := rows.Scan(&i.ID, &i.Name, &i.Userid); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Userid,
+			&i.Updatedat,
+			&i.Version,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -61,21 +137,84 @@ func (q *Queries) GetPlantsByUserId(ctx context.Context, userid int64) ([]Plant,
 	return items, nil
 }
 
+const getPlantsVersionByUserId = `-- name: GetPlantsVersionByUserId :one
+SELECT COUNT(*)::bigint AS plant_count,
+       COALESCE(MAX(updatedAt), 'epoch')::timestamptz AS updated_at
+FROM plants
+WHERE userId = $1
+`
+
+type GetPlantsVersionByUserIdRow struct {
+	PlantCount int64
+	UpdatedAt  time.Time
+}
+
+func (q *Queries) GetPlantsVersionByUserId(ctx context.Context, userid int64) (GetPlantsVersionByUserIdRow, error) {
+	row := q.db.QueryRow(ctx, getPlantsVersionByUserId, userid)
+	var i GetPlantsVersionByUserIdRow
+	err := row.Scan(&i.PlantCount, &i.UpdatedAt)
+	return i, err
+}
+
+const lockPlant = `-- name: LockPlant :exec
+SELECT id FROM plants WHERE id = $1 FOR UPDATE
+`
+
+// Holds the plant's row until the transaction ends, so care recorded for it
+// at the same time is checked for duplicates one at a time
+func (q *Queries) LockPlant(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, lockPlant, id)
+	return err
+}
+
+const patchPlant = `-- name: PatchPlant :one
+UPDATE plants
+SET name = COALESCE($1, name)
+WHERE id = $2 AND version = $3
+RETURNING id, name, userid, updatedat, version
+`
+
+type PatchPlantParams struct {
+	Name    pgtype.Text
+	ID      int64
+	Version int64
+}
+
+func (q *Queries) PatchPlant(ctx context.Context, arg PatchPlantParams) (Plant, error) {
+	row := q.db.QueryRow(ctx, patchPlant, arg.Name, arg.ID, arg.Version)
+	var i Plant
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Userid,
+		&i.Updatedat,
+		&i.Version,
+	)
+	return i, err
+}
+
 const updatePlant = `-- name: UpdatePlant :one
 UPDATE plants
 SET name = $2
-WHERE id = $1
-RETURNING id, name, userid
+WHERE id = $1 AND version = $3
+RETURNING id, name, userid, updatedat, version
 `
 
 type UpdatePlantParams struct {
-	ID   int64
-	Name string
+	ID      int64
+	Name    string
+	Version int64
 }
 
 func (q *Queries) UpdatePlant(ctx context.Context, arg UpdatePlantParams) (Plant, error) {
-	row := q.db.QueryRow(ctx, updatePlant, arg.ID, arg.Name)
+	row := q.db.QueryRow(ctx, updatePlant, arg.ID, arg.Name, arg.Version)
 	var i Plant
-	err := row.Scan(&i.ID, &i.Name, &i.Userid)
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Userid,
+		&i.Updatedat,
+		&i.Version,
+	)
 	return i, err
 }
@@ -0,0 +1,227 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: stats.sql
+
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const getEventCountsByPlantId = `-- name: GetEventCountsByPlantId :many
+SELECT date_trunc($1::text, e.timestamp)::timestamptz AS bucket,
+       e.eventtype,
+       COUNT(*)::bigint AS event_count
+FROM events e
+WHERE e.plantid = $2
+GROUP BY 1, 2
+ORDER BY 1, 2
+`
+
+type GetEventCountsByPlantIdParams struct {
+	Bucket  string
+	PlantID int64
+}
+
+type GetEventCountsByPlantIdRow struct {
+	Bucket     time.Time
+	Eventtype  int32
+	EventCount int64
+}
+
+func (q *Queries) GetEventCountsByPlantId(ctx context.Context, arg GetEventCountsByPlantIdParams) ([]GetEventCountsByPlantIdRow, error) {
+	rows, err := q.db.Query(ctx, getEventCountsByPlantId, arg.Bucket, arg.PlantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEventCountsByPlantIdRow
+	for rows.Next() {
+		var i GetEventCountsByPlantIdRow
+		if err := rows.Scan(&i.Bucket, &i.Eventtype, &i.EventCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventCountsByUserId = `-- name: GetEventCountsByUserId :many
+SELECT date_trunc($1::text, e.timestamp)::timestamptz AS bucket,
+       e.eventtype,
+       COUNT(*)::bigint AS event_count
+FROM events e
+JOIN plants p ON p.id = e.plantid
+WHERE p.userid = $2
+GROUP BY 1, 2
+ORDER BY 1, 2
+`
+
+type GetEventCountsByUserIdParams struct {
+	Bucket string
+	UserID int64
+}
+
+type GetEventCountsByUserIdRow struct {
+	Bucket     time.Time
+	Eventtype  int32
+	EventCount int64
+}
+
+func (q *Queries) GetEventCountsByUserId(ctx context.Context, arg GetEventCountsByUserIdParams) ([]GetEventCountsByUserIdRow, error) {
+	rows, err := q.db.Query(ctx, getEventCountsByUserId, arg.Bucket, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEventCountsByUserIdRow
+	for rows.Next() {
+		var i GetEventCountsByUserIdRow
+		if err := rows.Scan(&i.Bucket, &i.Eventtype, &i.EventCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIntervalStatsByPlantId = `-- name: GetIntervalStatsByPlantId :many
+WITH intervals AS (
+  SELECT e.eventtype,
+         (EXTRACT(EPOCH FROM e.timestamp - LAG(e.timestamp) OVER (PARTITION BY e.eventtype ORDER BY e.timestamp)) / 86400)::float8 AS days
+  FROM events e
+  WHERE e.plantid = $3
+)
+SELECT eventtype,
+       COUNT(*)::bigint AS event_count,
+       COUNT(days)::bigint AS interval_count,
+       COALESCE(AVG(days), 0)::float8 AS mean_days,
+       COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY days), 0)::float8 AS median_days,
+       COALESCE(MAX(days), 0)::float8 AS longest_gap_days,
+       (COUNT(days) FILTER (WHERE days <= CASE eventtype
+         WHEN 1 THEN $1::float8
+         ELSE $2::float8
+       END))::bigint AS on_time_count
+FROM intervals
+GROUP BY eventtype
+ORDER BY eventtype
+`
+
+type GetIntervalStatsByPlantIdParams struct {
+	WaterIntervalDays      float64
+	FertilizerIntervalDays float64
+	PlantID                int64
+}
+
+type GetIntervalStatsByPlantIdRow struct {
+	Eventtype      int32
+	EventCount     int64
+	IntervalCount  int64
+	MeanDays       float64
+	MedianDays     float64
+	LongestGapDays float64
+	OnTimeCount    int64
+}
+
+func (q *Queries) GetIntervalStatsByPlantId(ctx context.Context, arg GetIntervalStatsByPlantIdParams) ([]GetIntervalStatsByPlantIdRow, error) {
+	rows, err := q.db.Query(ctx, getIntervalStatsByPlantId, arg.WaterIntervalDays, arg.FertilizerIntervalDays, arg.PlantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetIntervalStatsByPlantIdRow
+	for rows.Next() {
+		var i GetIntervalStatsByPlantIdRow
+		if err := rows.Scan(
+			&i.Eventtype,
+			&i.EventCount,
+			&i.IntervalCount,
+			&i.MeanDays,
+			&i.MedianDays,
+			&i.LongestGapDays,
+			&i.OnTimeCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIntervalStatsByUserId = `-- name: GetIntervalStatsByUserId :many
+WITH intervals AS (
+  SELECT e.eventtype,
+         (EXTRACT(EPOCH FROM e.timestamp - LAG(e.timestamp) OVER (PARTITION BY e.plantid, e.eventtype ORDER BY e.timestamp)) / 86400)::float8 AS days
+  FROM events e
+  JOIN plants p ON p.id = e.plantid
+  WHERE p.userid = $3
+)
+SELECT eventtype,
+       COUNT(*)::bigint AS event_count,
+       COUNT(days)::bigint AS interval_count,
+       COALESCE(AVG(days), 0)::float8 AS mean_days,
+       COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY days), 0)::float8 AS median_days,
+       COALESCE(MAX(days), 0)::float8 AS longest_gap_days,
+       (COUNT(days) FILTER (WHERE days <= CASE eventtype
+         WHEN 1 THEN $1::float8
+         ELSE $2::float8
+       END))::bigint AS on_time_count
+FROM intervals
+GROUP BY eventtype
+ORDER BY eventtype
+`
+
+type GetIntervalStatsByUserIdParams struct {
+	WaterIntervalDays      float64
+	FertilizerIntervalDays float64
+	UserID                 int64
+}
+
+type GetIntervalStatsByUserIdRow struct {
+	Eventtype      int32
+	EventCount     int64
+	IntervalCount  int64
+	MeanDays       float64
+	MedianDays     float64
+	LongestGapDays float64
+	OnTimeCount    int64
+}
+
+func (q *Queries) GetIntervalStatsByUserId(ctx context.Context, arg GetIntervalStatsByUserIdParams) ([]GetIntervalStatsByUserIdRow, error) {
+	rows, err := q.db.Query(ctx, getIntervalStatsByUserId, arg.WaterIntervalDays, arg.FertilizerIntervalDays, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetIntervalStatsByUserIdRow
+	for rows.Next() {
+		var i GetIntervalStatsByUserIdRow
+		if err := rows.Scan(
+			&i.Eventtype,
+			&i.EventCount,
+			&i.IntervalCount,
+			&i.MeanDays,
+			&i.MedianDays,
+			&i.LongestGapDays,
+			&i.OnTimeCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
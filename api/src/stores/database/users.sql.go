@@ -31,6 +31,37 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 	return i, err
 }
 
+const createUsers = `-- name: CreateUsers :many
+INSERT INTO users (name, colour)
+SELECT unnest($1::text[]), unnest($2::text[])
+RETURNING id, name, colour
+`
+
+type CreateUsersParams struct {
+	Names   []string
+	Colours []string
+}
+
+func (q *Queries) CreateUsers(ctx context.Context, arg CreateUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, createUsers, arg.Names, arg.Colours)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(&i.ID, &i.Name, &i.Colour); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserById = `-- name: GetUserById :one
 SELECT id, name, colour FROM users
 WHERE id = $1
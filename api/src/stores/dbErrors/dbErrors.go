@@ -0,0 +1,115 @@
+package dbErrors
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ReidMason/plant-tracker/src/domainErrors"
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Wrap wraps db so that the errors the queries return are domainErrors:
+// missing rows become NotFound and constraint violations become Conflict,
+// NotFound or Validation. The original pgx error stays in the chain.
+//
+// It lives outside the sqlc generated database package so that regenerating
+// it can't remove it.
+func Wrap(db database.DBTX) database.DBTX {
+	return translatingDB{db}
+}
+
+type translatingDB struct {
+	db database.DBTX
+}
+
+func (t translatingDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := t.db.Exec(ctx, sql, args...)
+	return tag, translate(err)
+}
+
+func (t translatingDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := t.db.Query(ctx, sql, args...)
+	if err != nil {
+		return rows, translate(err)
+	}
+	return translatingRows{rows}, nil
+}
+
+func (t translatingDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return translatingRow{t.db.QueryRow(ctx, sql, args...)}
+}
+
+func (t translatingDB) CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	n, err := t.db.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	return n, translate(err)
+}
+
+type translatingRow struct {
+	pgx.Row
+}
+
+func (r translatingRow) Scan(dest ...any) error {
+	return translate(r.Row.Scan(dest...))
+}
+
+// Statement errors such as constraint violations in INSERT ... RETURNING
+// surface from Err or Scan rather than from Query itself
+type translatingRows struct {
+	pgx.Rows
+}
+
+func (r translatingRows) Scan(dest ...any) error {
+	return translate(r.Rows.Scan(dest...))
+}
+
+func (r translatingRows) Err() error {
+	return translate(r.Rows.Err())
+}
+
+// constraintErrors gives violations of the named constraints a kind and a
+// message that makes sense to the person using the API
+var constraintErrors = map[string]struct {
+	kind    domainErrors.Kind
+	message string
+	field   string
+}{
+	"users_name_key":           {domainErrors.Conflict, "A user with that name already exists", "name"},
+	"plants_userid_fkey":       {domainErrors.NotFound, "User not found", ""},
+	"events_plantid_fkey":      {domainErrors.NotFound, "Plant not found", ""},
+	"events_eventtype_fkey":    {domainErrors.Validation, "eventType must be 1 (water) or 2 (fertilize)", "eventType"},
+	"achievements_userid_fkey": {domainErrors.NotFound, "User not found", ""},
+}
+
+func translate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domainErrors.Wrap(domainErrors.NotFound, "Resource not found", err)
+	}
+
+	var pgError *pgconn.PgError
+	if !errors.As(err, &pgError) {
+		return err
+	}
+
+	if known, ok := constraintErrors[pgError.ConstraintName]; ok {
+		domainError := domainErrors.Wrap(known.kind, known.message, err)
+		domainError.Field = known.field
+		return domainError
+	}
+
+	switch pgError.Code {
+	case "23505": // unique_violation
+		return domainErrors.Wrap(domainErrors.Conflict, "Conflicts with an existing record", err)
+	case "23503": // foreign_key_violation
+		return domainErrors.Wrap(domainErrors.NotFound, "Referenced resource not found", err)
+	case "23502", "23514", "22001", "22003", "22P02": // not_null, check, too long, out of range, bad text
+		return domainErrors.Wrap(domainErrors.Validation, "Invalid value", err)
+	default:
+		return err
+	}
+}
@@ -11,4 +11,6 @@ type EventsStore interface {
 	GetEventById(ctx context.Context, id int64) (database.Event, error)
 	GetEventsByPlantId(ctx context.Context, plantid int64) ([]database.Event, error)
 	GetLatestEventsByTypeForPlant(ctx context.Context, plantid int64) ([]database.Event, error)
+	GetRecentEvent(ctx context.Context, arg database.GetRecentEventParams) (database.Event, error)
+	MergeIntoEvent(ctx context.Context, arg database.MergeIntoEventParams) (database.Event, error)
 }
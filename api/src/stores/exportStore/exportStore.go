@@ -0,0 +1,33 @@
+package exportStore
+
+import (
+	"context"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+)
+
+// ExportStore reads a user's data for export. Backups use the other stream
+// methods of the store New creates to read whole tables.
+type ExportStore interface {
+	GetUserById(ctx context.Context, id int64) (database.User, error)
+	StreamPlantsByUserId(ctx context.Context, userid int64, fn func(database.Plant) error) error
+	StreamEventsByUserId(ctx context.Context, userid int64, fn func(database.Event) error) error
+}
+
+type exportStore struct {
+	db      database.DBTX
+	queries *database.Queries
+}
+
+// New creates a store that runs its queries against db, which may be a
+// transaction
+func New(db database.DBTX) *exportStore {
+	return &exportStore{
+		db:      db,
+		queries: database.New(db),
+	}
+}
+
+func (s *exportStore) GetUserById(ctx context.Context, id int64) (database.User, error) {
+	return s.queries.GetUserById(ctx, id)
+}
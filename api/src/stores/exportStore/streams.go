@@ -0,0 +1,110 @@
+package exportStore
+
+import (
+	"context"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+	"github.com/jackc/pgx/v5"
+)
+
+// The queries in this file hand each row to a callback as it is read instead of
+// collecting the result set, so exports of large histories use constant memory.
+// sqlc only generates queries that collect every row, so they are written by
+// hand here rather than in the generated database package.
+
+const streamPlantsByUserId = `SELECT id, name, userid FROM plants WHERE userId = $1 ORDER BY id`
+
+func (s *exportStore) StreamPlantsByUserId(ctx context.Context, userid int64, fn func(database.Plant) error) error {
+	return streamRows(ctx, s.db, streamPlantsByUserId, []any{userid}, func(rows pgx.Rows) (database.Plant, error) {
+		var i database.Plant
+		err := rows.Scan(&i.ID, &i.Name, &i.Userid)
+		return i, err
+	}, fn)
+}
+
+const streamEventsByUserId = `SELECT e.id, e.plantid, e.eventtype, e.note, e.timestamp, e.actorids
+FROM events e
+JOIN plants p ON p.id = e.plantid
+WHERE p.userid = $1
+ORDER BY e.timestamp, e.id`
+
+func (s *exportStore) StreamEventsByUserId(ctx context.Context, userid int64, fn func(database.Event) error) error {
+	return streamRows(ctx, s.db, streamEventsByUserId, []any{userid}, scanEvent, fn)
+}
+
+const streamUsers = `SELECT id, name, colour FROM users ORDER BY id`
+
+func (s *exportStore) StreamUsers(ctx context.Context, fn func(database.User) error) error {
+	return streamRows(ctx, s.db, streamUsers, nil, func(rows pgx.Rows) (database.User, error) {
+		var i database.User
+		err := rows.Scan(&i.ID, &i.Name, &i.Colour)
+		return i, err
+	}, fn)
+}
+
+const streamPlants = `SELECT id, name, userid FROM plants ORDER BY id`
+
+func (s *exportStore) StreamPlants(ctx context.Context, fn func(database.Plant) error) error {
+	return streamRows(ctx, s.db, streamPlants, nil, func(rows pgx.Rows) (database.Plant, error) {
+		var i database.Plant
+		err := rows.Scan(&i.ID, &i.Name, &i.Userid)
+		return i, err
+	}, fn)
+}
+
+const streamEventTypes = `SELECT id, name FROM eventTypes ORDER BY id`
+
+func (s *exportStore) StreamEventTypes(ctx context.Context, fn func(database.Eventtype) error) error {
+	return streamRows(ctx, s.db, streamEventTypes, nil, func(rows pgx.Rows) (database.Eventtype, error) {
+		var i database.Eventtype
+		err := rows.Scan(&i.ID, &i.Name)
+		return i, err
+	}, fn)
+}
+
+const streamEvents = `SELECT id, plantid, eventtype, note, timestamp, actorids FROM events ORDER BY id`
+
+func (s *exportStore) StreamEvents(ctx context.Context, fn func(database.Event) error) error {
+	return streamRows(ctx, s.db, streamEvents, nil, scanEvent, fn)
+}
+
+const streamAchievements = `SELECT userid, code, earnedat FROM achievements ORDER BY userid, code`
+
+func (s *exportStore) StreamAchievements(ctx context.Context, fn func(database.Achievement) error) error {
+	return streamRows(ctx, s.db, streamAchievements, nil, func(rows pgx.Rows) (database.Achievement, error) {
+		var i database.Achievement
+		err := rows.Scan(&i.Userid, &i.Code, &i.Earnedat)
+		return i, err
+	}, fn)
+}
+
+func scanEvent(rows pgx.Rows) (database.Event, error) {
+	var i database.Event
+	err := rows.Scan(
+		&i.ID,
+		&i.Plantid,
+		&i.Eventtype,
+		&i.Note,
+		&i.Timestamp,
+		&i.Actorids,
+	)
+	return i, err
+}
+
+func streamRows[T any](ctx context.Context, db database.DBTX, query string, args []any, scan func(pgx.Rows) (T, error), fn func(T) error) error {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		i, err := scan(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
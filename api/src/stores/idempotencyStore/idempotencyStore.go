@@ -0,0 +1,16 @@
+package idempotencyStore
+
+import (
+	"context"
+	"time"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+)
+
+type IdempotencyStore interface {
+	ClaimIdempotencyKey(ctx context.Context, arg database.ClaimIdempotencyKeyParams) (database.IdempotencyKey, error)
+	GetIdempotencyKey(ctx context.Context, arg database.GetIdempotencyKeyParams) (database.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, arg database.CompleteIdempotencyKeyParams) error
+	ReleaseIdempotencyKey(ctx context.Context, arg database.ReleaseIdempotencyKeyParams) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, createdat time.Time) (int64, error)
+}
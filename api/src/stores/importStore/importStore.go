@@ -0,0 +1,15 @@
+package importStore
+
+import (
+	"context"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+)
+
+type ImportStore interface {
+	GetUserById(ctx context.Context, id int64) (database.User, error)
+	GetPlantsByUserId(ctx context.Context, userId int64) ([]database.Plant, error)
+	CreatePlant(ctx context.Context, arg database.CreatePlantParams) (database.Plant, error)
+	GetEventsByPlantId(ctx context.Context, plantId int64) ([]database.Event, error)
+	CreateEvents(ctx context.Context, arg []database.CreateEventsParams) (int64, error)
+}
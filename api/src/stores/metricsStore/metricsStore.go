@@ -0,0 +1,11 @@
+package metricsStore
+
+import (
+	"context"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+)
+
+type MetricsStore interface {
+	GetPlantCareSnapshot(ctx context.Context, arg database.GetPlantCareSnapshotParams) ([]database.GetPlantCareSnapshotRow, error)
+}
@@ -11,4 +11,7 @@ type PlantsStore interface {
 	GetPlantById(ctx context.Context, id int64) (database.Plant, error)
 	CreatePlant(ctx context.Context, arg database.CreatePlantParams) (database.Plant, error)
 	UpdatePlant(ctx context.Context, arg database.UpdatePlantParams) (database.Plant, error)
+	PatchPlant(ctx context.Context, arg database.PatchPlantParams) (database.Plant, error)
+	DeletePlant(ctx context.Context, arg database.DeletePlantParams) (int64, error)
+	GetPlantsVersionByUserId(ctx context.Context, userId int64) (database.GetPlantsVersionByUserIdRow, error)
 }
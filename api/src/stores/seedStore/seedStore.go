@@ -0,0 +1,14 @@
+package seedStore
+
+import (
+	"context"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+)
+
+type SeedStore interface {
+	GetUsers(ctx context.Context) ([]database.User, error)
+	CreateUsers(ctx context.Context, arg database.CreateUsersParams) ([]database.User, error)
+	CreatePlants(ctx context.Context, arg database.CreatePlantsParams) ([]database.Plant, error)
+	CreateEvents(ctx context.Context, arg []database.CreateEventsParams) (int64, error)
+}
@@ -0,0 +1,14 @@
+package statsStore
+
+import (
+	"context"
+
+	"github.com/ReidMason/plant-tracker/src/stores/database"
+)
+
+type StatsStore interface {
+	GetIntervalStatsByUserId(ctx context.Context, arg database.GetIntervalStatsByUserIdParams) ([]database.GetIntervalStatsByUserIdRow, error)
+	GetIntervalStatsByPlantId(ctx context.Context, arg database.GetIntervalStatsByPlantIdParams) ([]database.GetIntervalStatsByPlantIdRow, error)
+	GetEventCountsByUserId(ctx context.Context, arg database.GetEventCountsByUserIdParams) ([]database.GetEventCountsByUserIdRow, error)
+	GetEventCountsByPlantId(ctx context.Context, arg database.GetEventCountsByPlantIdParams) ([]database.GetEventCountsByPlantIdRow, error)
+}
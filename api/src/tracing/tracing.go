@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ServiceName = "plant-tracker-api"
+
+	instrumentationName = "github.com/ReidMason/plant-tracker"
+)
+
+// Exporters that Setup accepts
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+type Options struct {
+	// Exporter is one of none, otlp or stdout
+	Exporter string
+	// Endpoint overrides the OTLP/HTTP endpoint, otherwise the standard
+	// OTEL_EXPORTER_OTLP_* variables apply
+	Endpoint       string
+	SampleRatio    float64
+	ServiceVersion string
+}
+
+// Setup installs the global tracer provider and propagator. The returned
+// function flushes buffered spans and must be called before exiting. With the
+// none exporter nothing is installed and spans are no-ops.
+func Setup(ctx context.Context, options Options) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch options.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var exporterOptions []otlptracehttp.Option
+		if options.Endpoint != "" {
+			exporterOptions = append(exporterOptions, otlptracehttp.WithEndpointURL(options.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, exporterOptions...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", options.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", options.Exporter, err)
+	}
+
+	serviceResource, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(options.ServiceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe service for tracing: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(serviceResource),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Start begins a span for a unit of work such as a service method. Callers
+// must end the span, usually with defer span.End().
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name)
+}
+
+// Middleware starts a server span for each request, continuing any trace the
+// caller propagated. Spans are named after the route by RouteName.
+func Middleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http.request", otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method
+	}))
+}
+
+// RouteName renames the request span after the matched route once it has been
+// served. It must sit between the mux and any middleware that copies the
+// request, as the mux records the route on the request it is given.
+func RouteName(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if r.Pattern == "" {
+				return
+			}
+
+			route := r.Pattern
+			if _, path, found := strings.Cut(route, " "); found {
+				route = path
+			}
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// QueryTracer creates spans for pgx queries, named after the sqlc query
+func QueryTracer() pgx.QueryTracer {
+	return otelpgx.NewTracer(otelpgx.WithSpanNameFunc(queryName))
+}
+
+// queryName uses the "-- name: GetPlantById :one" comment sqlc puts at the start
+// of every query, falling back to the SQL operation for hand written queries
+func queryName(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if name, found := strings.CutPrefix(sql, "-- name: "); found {
+		if name, _, found = strings.Cut(name, " "); found {
+			return name
+		}
+	}
+
+	operation, _, _ := strings.Cut(sql, " ")
+	return strings.ToUpper(operation)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with -ldflags "-X main.version=... -X main.commit=..."
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// buildCommit falls back to the VCS revision Go stamps into binaries built
+// from a git checkout
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+
+	return "unknown"
+}
+
+func runVersion(args []string) {
+	fmt.Printf("Version:        %s\n", version)
+	fmt.Printf("Commit:         %s\n", buildCommit())
+	fmt.Printf("Go version:     %s\n", runtime.Version())
+
+	latest, err := latestMigrationVersion()
+	if err != nil {
+		exitWithError("Failed to read migrations", err)
+	}
+	fmt.Printf("Schema version: %d\n", latest)
+}
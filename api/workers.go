@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// workers runs background goroutines for the lifetime of the server so they
+// can all be told to stop, and waited for, when it shuts down
+type workers struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newWorkers() *workers {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &workers{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Go starts fn, which must return promptly once its context is cancelled
+func (w *workers) Go(fn func(ctx context.Context)) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		fn(w.ctx)
+	}()
+}
+
+// Stop cancels every worker and waits for them to return or for ctx to expire
+func (w *workers) Stop(ctx context.Context) error {
+	w.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
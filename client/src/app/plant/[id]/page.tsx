@@ -201,6 +201,7 @@ export default function PlantPage({ params, searchParams }: PlantPageProps) {
                   name={plant.name}
                   plantId={plant.id}
                   userId={userId}
+                  version={plant.version}
                 />
               </div>
             </CardHeader>
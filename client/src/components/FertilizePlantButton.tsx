@@ -1,6 +1,6 @@
 "use client";
 
-import { useState } from "react";
+import { useRef, useState } from "react";
 import { Button } from "@/components/ui/button";
 import { Loader2, Sparkles } from "lucide-react";
 import { createFertilizeEvent } from "@/lib/services/eventsService/eventsService";
@@ -19,20 +19,28 @@ interface FertilizePlantButtonProps {
 
 export default function FertilizePlantButton({ userId, plantId, onSuccess, disabled, needsFertilizer, onClick, size = "sm", className, compact = false }: FertilizePlantButtonProps) {
   const [isSubmitting, setIsSubmitting] = useState(false);
+  // One key per fertilizing, kept until it succeeds so retries can't add a second event
+  const idempotencyKey = useRef<string | null>(null);
+  const submitting = useRef(false);
 
   const handleFertilizePlant = async () => {
+    // A double tap can land before the button is disabled
+    if (submitting.current) return;
+    submitting.current = true;
+    idempotencyKey.current ??= crypto.randomUUID();
     setIsSubmitting(true);
     try {
       const result = await createFertilizeEvent(userId, plantId, { 
         note: "",
         eventType: 2 // This will be overridden by the service, but required by the type
-      });
+      }, idempotencyKey.current);
 
       if (!result.ok) {
         console.error("Failed to fertilize plant:", result.error.message);
         alert("Failed to fertilize plant. Please try again.");
         return;
       }
+      idempotencyKey.current = null;
 
       if (onSuccess) {
         onSuccess();
@@ -41,6 +49,7 @@ export default function FertilizePlantButton({ userId, plantId, onSuccess, disab
       console.error("Error fertilizing plant:", error);
       alert("Error fertilizing plant. Please try again.");
     } finally {
+      submitting.current = false;
       setIsSubmitting(false);
     }
   };
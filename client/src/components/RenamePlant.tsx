@@ -7,9 +7,10 @@ interface RenamePlantProps {
   name: string;
   plantId: number | string;
   userId: number | string;
+  version: number;
 }
 
-export default function RenamePlant({ name, plantId, userId }: RenamePlantProps) {
+export default function RenamePlant({ name, plantId, userId, version }: RenamePlantProps) {
   const [editing, setEditing] = useState(false);
   const [newName, setNewName] = useState(name);
   const [loading, setLoading] = useState(false);
@@ -18,7 +19,7 @@ export default function RenamePlant({ name, plantId, userId }: RenamePlantProps)
   const handleRename = async () => {
     setLoading(true);
     setError(null);
-    const result = await updatePlantName(userId, plantId, newName);
+    const result = await updatePlantName(userId, plantId, newName, version);
     setLoading(false);
     if (result.ok) {
       setEditing(false);
@@ -1,6 +1,6 @@
 "use client";
 
-import { useState } from "react";
+import { useRef, useState } from "react";
 import { Button } from "@/components/ui/button";
 import { Droplet, Loader2 } from "lucide-react";
 import { createWateringEvent } from "@/lib/services/eventsService/eventsService";
@@ -19,16 +19,24 @@ interface WaterPlantButtonProps {
 
 export default function WaterPlantButton({ userId, plantId, onSuccess, disabled, needsWatering, onClick, size = "sm", className, compact = false }: WaterPlantButtonProps) {
   const [isSubmitting, setIsSubmitting] = useState(false);
+  // One key per watering, kept until it succeeds so retries can't add a second event
+  const idempotencyKey = useRef<string | null>(null);
+  const submitting = useRef(false);
 
   const handleWaterPlant = async () => {
+    // A double tap can land before the button is disabled
+    if (submitting.current) return;
+    submitting.current = true;
+    idempotencyKey.current ??= crypto.randomUUID();
     setIsSubmitting(true);
     try {
-      const result = await createWateringEvent(userId, plantId, { eventType: 1, note: "" });
+      const result = await createWateringEvent(userId, plantId, { eventType: 1, note: "" }, idempotencyKey.current);
 
       if (!result.ok) {
         console.error("Failed to water plant:", result.error.message);
         return;
       }
+      idempotencyKey.current = null;
 
       if (onSuccess) {
         onSuccess();
@@ -36,6 +44,7 @@ export default function WaterPlantButton({ userId, plantId, onSuccess, disabled,
     } catch (error) {
       console.error("Error watering plant:", error);
     } finally {
+      submitting.current = false;
       setIsSubmitting(false);
     }
   };
@@ -32,12 +32,12 @@ class BaseApi {
     return this.request<T>(endpoint, 'GET');
   }
 
-  async post<T, D = unknown>(endpoint: string, data?: D): Promise<Result<T>> {
-    return this.request<T>(endpoint, 'POST', data);
+  async post<T, D = unknown>(endpoint: string, data?: D, headers?: Record<string, string>): Promise<Result<T>> {
+    return this.request<T>(endpoint, 'POST', data, headers);
   }
 
-  async put<T, D = unknown>(endpoint: string, data?: D): Promise<Result<T>> {
-    return this.request<T>(endpoint, 'PUT', data);
+  async put<T, D = unknown>(endpoint: string, data?: D, headers?: Record<string, string>): Promise<Result<T>> {
+    return this.request<T>(endpoint, 'PUT', data, headers);
   }
 
   async delete<T>(endpoint: string): Promise<Result<T>> {
@@ -47,12 +47,14 @@ class BaseApi {
   private async request<T>(
     endpoint: string,
     method: string,
-    data?: unknown
+    data?: unknown,
+    extraHeaders?: Record<string, string>
   ): Promise<Result<T>> {
     const url = `${this.baseUrl}${endpoint}`;
 
     const headers: HeadersInit = {
       'Content-Type': 'application/json',
+      ...extraHeaders,
     };
 
     const config: RequestInit = {
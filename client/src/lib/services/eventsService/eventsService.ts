@@ -9,16 +9,22 @@ export async function getPlantEvents(userId: string | number, plantId: string |
   return await baseApi.get<Event[]>(`/users/${userId}/plants/${plantId}/events`);
 }
 
-export async function createWateringEvent(userId: string | number, plantId: string | number, data: CreateEventRequest): Promise<Result<Event>> {
+// Sending the same idempotencyKey again, e.g. when retrying after an error,
+// returns the event the first attempt created instead of adding another
+function idempotencyHeaders(idempotencyKey?: string): Record<string, string> | undefined {
+  return idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined;
+}
+
+export async function createWateringEvent(userId: string | number, plantId: string | number, data: CreateEventRequest, idempotencyKey?: string): Promise<Result<Event>> {
   return await baseApi.post<Event, CreateEventRequest>(`/users/${userId}/plants/${plantId}/events`, {
     ...data,
     eventType: EventType.Water
-  });
+  }, idempotencyHeaders(idempotencyKey));
 }
 
-export async function createFertilizeEvent(userId: string | number, plantId: string | number, data: CreateEventRequest): Promise<Result<Event>> {
+export async function createFertilizeEvent(userId: string | number, plantId: string | number, data: CreateEventRequest, idempotencyKey?: string): Promise<Result<Event>> {
   return await baseApi.post<Event, CreateEventRequest>(`/users/${userId}/plants/${plantId}/events`, {
     ...data,
     eventType: EventType.Fertilize
-  });
+  }, idempotencyHeaders(idempotencyKey));
 }
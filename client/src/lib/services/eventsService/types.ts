@@ -9,6 +9,8 @@ export interface Event {
   typeId: EventType;
   note: string;
   timestamp: string;
+  // Users who did the care, more than one when a housemate's duplicate was merged in
+  actorIds: number[];
 }
 
 export interface CreateEventRequest {
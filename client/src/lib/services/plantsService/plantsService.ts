@@ -11,11 +11,13 @@ const EventSchema = z.object({
   typeId: z.number(),
   note: z.string(),
   timestamp: z.string(),
+  actorIds: z.array(z.number()),
 });
 
 const PlantSchema = z.object({
   id: z.number(),
   name: z.string(),
+  version: z.number(),
   lastWaterEvent: EventSchema.nullable().optional(),
   nextWaterDue: z
     .string()
@@ -132,8 +134,10 @@ export async function createPlant(userId: string | number, plantData: CreatePlan
   }
 }
 
-export async function updatePlantName(userId: string | number, plantId: string | number, name: string): Promise<Result<Plant>> {
-  const apiResult = await baseApi.put<unknown, { name: string }>(`/users/${userId}/plants/${plantId}`, { name });
+// version is the plant version the rename was based on, so a plant changed
+// by someone else in the meantime isn't overwritten
+export async function updatePlantName(userId: string | number, plantId: string | number, name: string, version: number): Promise<Result<Plant>> {
+  const apiResult = await baseApi.put<unknown, { name: string }>(`/users/${userId}/plants/${plantId}`, { name }, { 'If-Match': `"${version}"` });
   if (!apiResult.ok) return apiResult;
   try {
     const plant = PlantSchema.parse(apiResult.value);